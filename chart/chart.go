@@ -0,0 +1,191 @@
+package chart
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Style wraps drawn glyphs, e.g.: in ansi color sequences.
+type Style struct{ Prefix, Suffix string }
+
+func (s Style) wrap(str string) string {
+	if str == "" {
+		return str
+	}
+	return s.Prefix + str + s.Suffix
+}
+
+type Bar struct {
+	Label string
+	Value int
+}
+
+var eighths = []rune(" ▏▎▍▌▋▊▉█")
+
+func bar(v, max, width int) string {
+	if max <= 0 || width <= 0 {
+		return ""
+	}
+	n := v * width * 8 / max
+	if n == 0 && v > 0 {
+		n = 1
+	}
+	return strings.Repeat("█", n/8) + strings.TrimSpace(string(eighths[n%8]))
+}
+
+// Bars draws a horizontal bar for each value, scaled to fit within width.
+func Bars(w io.Writer, bars []Bar, width int, style Style) error {
+	labelw, valuew, max := 0, 0, 0
+	for _, b := range bars {
+		if l := runewidth.StringWidth(b.Label); l > labelw {
+			labelw = l
+		}
+		if l := len(fmt.Sprint(b.Value)); l > valuew {
+			valuew = l
+		}
+		if b.Value > max {
+			max = b.Value
+		}
+	}
+
+	barw := width - labelw - valuew - 2
+	if barw < 10 {
+		barw = 10
+	}
+
+	var s strings.Builder
+	for _, b := range bars {
+		s.WriteString(runewidth.FillRight(b.Label, labelw))
+		fmt.Fprintf(&s, " %*d ", valuew, b.Value)
+		s.WriteString(style.wrap(bar(b.Value, max, barw)))
+		s.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, s.String())
+	return err
+}
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline returns a single line chart of values, only the last width
+// values are drawn.
+func Sparkline(values []int, width int) string {
+	if width > 0 && len(values) > width {
+		values = values[len(values)-width:]
+	}
+	max := 0
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+
+	s := make([]rune, len(values))
+	for i, v := range values {
+		s[i] = sparks[0]
+		if max != 0 {
+			s[i] = sparks[v*(len(sparks)-1)/max]
+		}
+	}
+	return string(s)
+}
+
+var shades = []string{"·", "░", "▒", "▓", "█"}
+
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Heatmap draws a calendar with a column per week and a row per weekday,
+// shaded by the count for each day. The most recent weeks that fit within
+// width are drawn, ending at the week of the latest day in counts.
+func Heatmap(w io.Writer, counts map[time.Time]int, width int, style Style) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	var first, last time.Time
+	max := 0
+	days := make(map[time.Time]int, len(counts))
+	for d, n := range counts {
+		d = day(d)
+		days[d] += n
+		if first.IsZero() || d.Before(first) {
+			first = d
+		}
+		if d.After(last) {
+			last = d
+		}
+	}
+	for _, n := range days {
+		if n > max {
+			max = n
+		}
+	}
+
+	monday := func(t time.Time) time.Time {
+		return t.AddDate(0, 0, -(int(t.Weekday())+6)%7)
+	}
+
+	const labelw = 4
+	weeks := (width - labelw) / 2
+	if weeks < 1 {
+		weeks = 1
+	}
+	end := monday(last)
+	start := monday(first)
+	if min := end.AddDate(0, 0, -7*(weeks-1)); start.Before(min) {
+		start = min
+	}
+	weeks = int(end.Sub(start).Hours()/24/7) + 1
+
+	header := []rune(strings.Repeat(" ", weeks*2+6))
+	free := 0
+	for i := 0; i < weeks; i++ {
+		wk := start.AddDate(0, 0, 7*i)
+		if i != 0 && wk.AddDate(0, 0, -7).Month() == wk.Month() {
+			continue
+		}
+		m := wk.Format("Jan")
+		if i == 0 || wk.Month() == time.January {
+			m = wk.Format("Jan 06")
+		}
+		if i*2 < free || i*2+len(m) > len(header) {
+			continue
+		}
+		copy(header[i*2:], []rune(m))
+		free = i*2 + len(m) + 1
+	}
+
+	var s strings.Builder
+	s.WriteString(strings.Repeat(" ", labelw))
+	s.WriteString(strings.TrimRight(string(header), " "))
+	s.WriteString("\n")
+
+	labels := []string{"Mon", "", "Wed", "", "Fri", "", "Sun"}
+	for wd := 0; wd < 7; wd++ {
+		s.WriteString(runewidth.FillRight(labels[wd], labelw))
+		for i := 0; i < weeks; i++ {
+			d := start.AddDate(0, 0, 7*i+wd)
+			if d.After(last) {
+				break
+			}
+			n := days[d]
+			if n == 0 {
+				s.WriteString(shades[0])
+			} else {
+				lvl := len(shades) - 1
+				s.WriteString(style.wrap(shades[(n*lvl+max-1)/max]))
+			}
+			s.WriteString(" ")
+		}
+		s.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, s.String())
+	return err
+}
@@ -0,0 +1,308 @@
+package chart
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Span is a labelled period from From up to but excluding To.
+// Open spans have no known end yet and end at the time of rendering.
+type Span struct {
+	From, To time.Time
+	Label    string
+	Title    string
+	Open     bool
+}
+
+type Lane struct {
+	Label string
+	Spans []Span
+}
+
+// timelineRange returns the first and last day of all spans.
+func timelineRange(lanes []Lane) (from, to time.Time) {
+	for _, l := range lanes {
+		for _, s := range l.Spans {
+			if from.IsZero() || s.From.Before(from) {
+				from = day(s.From)
+			}
+			if s.To.After(to) {
+				to = day(s.To)
+			}
+		}
+	}
+	return
+}
+
+// stack assigns each span to the first row in which it doesn't overlap
+// any other span.
+func stack(spans []Span) [][]int {
+	rows := make([][]int, 0, 1)
+	ends := make([]time.Time, 0, 1)
+	for i, s := range spans {
+		placed := false
+		for r := range rows {
+			if !s.From.Before(ends[r]) {
+				rows[r] = append(rows[r], i)
+				ends[r] = s.To
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			rows = append(rows, []int{i})
+			ends = append(ends, s.To)
+		}
+	}
+	if len(rows) == 0 {
+		rows = append(rows, nil)
+	}
+	return rows
+}
+
+// Timeline draws a lane per Lane with a bar per Span scaled to fit within
+// width. Spans should be sorted by their start.
+func Timeline(w io.Writer, lanes []Lane, width int, style Style) error {
+	from, to := timelineRange(lanes)
+	if from.IsZero() {
+		return nil
+	}
+
+	labelw := 0
+	for _, l := range lanes {
+		if lw := runewidth.StringWidth(l.Label); lw > labelw {
+			labelw = lw
+		}
+	}
+
+	cols := width - labelw - 1
+	if cols < 10 {
+		cols = 10
+	}
+	total := int(to.Sub(from).Hours()/24) + 1
+	perCol := (total + cols - 1) / cols
+	if perCol < 1 {
+		perCol = 1
+	}
+	cols = (total + perCol - 1) / perCol
+
+	col := func(t time.Time) int {
+		return int(day(t).Sub(from).Hours()/24) / perCol
+	}
+	cells := func(s Span) (int, int) {
+		a, b := col(s.From), col(s.To.AddDate(0, 0, -1))
+		if b >= cols {
+			b = cols - 1
+		}
+		if b < a {
+			b = a
+		}
+		return a, b
+	}
+
+	var out strings.Builder
+	header := []rune(strings.Repeat(" ", cols+6))
+	free := 0
+	for c := 0; c < cols; c++ {
+		d := from.AddDate(0, 0, c*perCol)
+		if c != 0 && d.AddDate(0, 0, -perCol).Month() == d.Month() {
+			continue
+		}
+		m := d.Format("Jan")
+		if c == 0 || d.Month() == time.January {
+			m = d.Format("Jan 06")
+		}
+		if c < free {
+			continue
+		}
+		copy(header[c:], []rune(m))
+		free = c + len(m) + 1
+	}
+	out.WriteString(strings.Repeat(" ", labelw+1))
+	out.WriteString(strings.TrimRight(string(header), " "))
+	out.WriteString("\n")
+
+	for _, l := range lanes {
+		for r, row := range stack(l.Spans) {
+			line := []rune(strings.Repeat("·", cols))
+			bars := make([]bool, cols)
+			spans := make([]int, cols)
+			for _, i := range row {
+				s := l.Spans[i]
+				a, b := cells(s)
+				for c := a; c <= b; c++ {
+					bars[c] = true
+					spans[c]++
+					line[c] = '━'
+				}
+				if s.Open {
+					line[b] = '▶'
+				}
+			}
+			// Only label spans with enough cells of their own, shared cells
+			// belong to adjacent spans.
+			for _, i := range row {
+				s := l.Spans[i]
+				a, b := cells(s)
+				if s.Open {
+					b--
+				}
+				for a <= b && spans[a] != 1 {
+					a++
+				}
+				n := runewidth.StringWidth(s.Label)
+				if n == 0 || a+n-1 > b || slices.ContainsFunc(spans[a:a+n], func(v int) bool { return v != 1 }) {
+					continue
+				}
+				copy(line[a:], []rune(s.Label))
+			}
+
+			lbl := ""
+			if r == 0 {
+				lbl = l.Label
+			}
+			out.WriteString(runewidth.FillRight(lbl, labelw))
+			out.WriteString(" ")
+			for c := 0; c < cols; {
+				n := c
+				for n < cols && bars[n] == bars[c] {
+					n++
+				}
+				str := string(line[c:n])
+				if bars[c] {
+					str = style.wrap(str)
+				}
+				out.WriteString(str)
+				c = n
+			}
+			out.WriteString("\n")
+		}
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// TimelineSVG renders the lanes as an svg image width pixels wide.
+func TimelineSVG(w io.Writer, lanes []Lane, width int) error {
+	from, to := timelineRange(lanes)
+	const (
+		labelw = 180
+		laneh  = 22
+		barh   = 16
+		top    = 24
+		// charw is the approximate width of a character at font-size 11.
+		charw = 7
+	)
+	if width <= labelw {
+		width = 1000
+	}
+
+	total := to.Sub(from).Hours() / 24
+	if total < 1 {
+		total = 1
+	}
+	scale := float64(width-labelw) / total
+	x := func(t time.Time) float64 {
+		return labelw + day(t).Sub(from).Hours()/24*scale
+	}
+
+	type placed struct {
+		lane  int
+		row   int
+		spans []int
+	}
+	rows := make([]placed, 0, len(lanes))
+	for i, l := range lanes {
+		for r, row := range stack(l.Spans) {
+			rows = append(rows, placed{i, r, row})
+		}
+	}
+
+	height := top + len(rows)*laneh + 4
+	var out strings.Builder
+	fmt.Fprintf(
+		&out,
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="11">`+"\n",
+		width,
+		height,
+	)
+
+	if !from.IsZero() {
+		m := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+		for ; !m.After(to); m = m.AddDate(0, 1, 0) {
+			mx := x(m)
+			if m.Before(from) {
+				continue
+			}
+			label := m.Format("Jan")
+			if m.Month() == time.January || m.Equal(from) {
+				label = m.Format("Jan 2006")
+			}
+			fmt.Fprintf(&out, `<line x1="%.1f" y1="%d" x2="%.1f" y2="%d" stroke="#ddd"/>`+"\n", mx, top-6, mx, height)
+			fmt.Fprintf(&out, `<text x="%.1f" y="%d" fill="#666">%s</text>`+"\n", mx+2, top-10, label)
+		}
+	}
+
+	for n, p := range rows {
+		y := top + n*laneh
+		l := lanes[p.lane]
+		if p.row == 0 {
+			fmt.Fprintf(
+				&out,
+				`<text x="4" y="%d">%s</text>`+"\n",
+				y+barh-4,
+				html.EscapeString(l.Label),
+			)
+		}
+		for _, i := range p.spans {
+			s := l.Spans[i]
+			x1, x2 := x(s.From), x(s.To)
+			if x2 > float64(width) {
+				x2 = float64(width)
+			}
+			if x2-x1 < scale {
+				x2 = x1 + scale
+			}
+			fill := "#4a8"
+			if s.Open {
+				fill = "#c44"
+			}
+			title := s.Title
+			if title == "" {
+				title = s.Label
+			}
+			fmt.Fprintf(
+				&out,
+				`<g><title>%s</title><rect x="%.1f" y="%d" width="%.1f" height="%d" rx="3" fill="%s"/>`,
+				html.EscapeString(title),
+				x1,
+				y,
+				x2-x1,
+				barh,
+				fill,
+			)
+			if float64(runewidth.StringWidth(s.Label)*charw+6) > x2-x1 {
+				out.WriteString("</g>\n")
+				continue
+			}
+			fmt.Fprintf(
+				&out,
+				`<text x="%.1f" y="%d" fill="#fff">%s</text></g>`+"\n",
+				x1+3,
+				y+barh-4,
+				html.EscapeString(s.Label),
+			)
+		}
+	}
+
+	out.WriteString("</svg>\n")
+	_, err := io.WriteString(w, out.String())
+	return err
+}
@@ -4,10 +4,13 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/containerd/console"
+	"github.com/frizinak/film-rolls/config"
 	"github.com/frizinak/film-rolls/db"
+	"github.com/frizinak/film-rolls/table"
 )
 
 func exit(err error) {
@@ -23,26 +26,217 @@ const (
 	formatPlain  = "plain"
 	formatPretty = "pretty"
 
-	modeLog   = "log"
-	modeStock = "stock"
-	modeTags  = "tags"
+	outputTerminal = "terminal"
+	outputMarkdown = "markdown"
+	outputHTML     = "html"
+	outputCSV      = "csv"
+	outputAsciiDoc = "adoc"
+	outputOrg      = "org"
+	outputJSON     = "json"
+	outputSVG      = "svg"
+	outputGeoJSON  = "geojson"
+	outputGPX      = "gpx"
+
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+
+	modeLog      = "log"
+	modeStock    = "stock"
+	modeTags     = "tags"
+	modeStats    = "stats"
+	modeChart    = "chart"
+	modeTimeline = "timeline"
+	modeCost     = "cost"
+	modeShopping = "shopping"
+	modeCheck    = "check"
+	modeFrames   = "frames"
+	modeImport   = "import"
+	modeMap      = "map"
+	modeGeotag   = "geotag"
+	modeXMP      = "xmp"
+	modeExif     = "exif"
+	modeScans    = "scans"
+	modeScaffold = "scaffold"
+	modeRename   = "rename"
 )
 
+func groupList(groups []db.GroupBy) string {
+	l := make([]string, len(groups))
+	for i := range groups {
+		l[i] = string(groups[i])
+	}
+	return strings.Join(l, "|")
+}
+
+func chartList() string {
+	l := make([]string, len(db.Charts))
+	for i := range db.Charts {
+		l[i] = string(db.Charts[i])
+	}
+	return strings.Join(l, "|")
+}
+
 func main() {
 	var verbose bool
 	var format string
 	var mode string
+	var output string
+	var sep string
 	var id string
 	var md bool
 	var nh bool
+	var configFile string
+	var theme string
+	var color string
+	var groupBy string
+	var chart string
+	var at string
+	var expiryMonths int
+	var attrs string
+	var attrCols string
+	var importFile string
+	var dryRun bool
+	var near string
+	var gpxFile string
+	var gpxDiff time.Duration
+	var tz string
+	var scanDir string
+	var xmpStyle string
+	var overwrite bool
+	var pattern string
+	var undo string
 	conf := db.TableConfigDefault()
 	flag.BoolVar(&verbose, "v", false, "Be verbose.")
-	flag.StringVar(&mode, "m", modeLog, fmt.Sprintf("Mode: %s, %s or %s", modeLog, modeStock, modeTags))
+	flag.StringVar(&mode, "m", modeLog, fmt.Sprintf(
+		"Mode: %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s or %s",
+		modeLog,
+		modeStock,
+		modeTags,
+		modeStats,
+		modeChart,
+		modeTimeline,
+		modeCost,
+		modeShopping,
+		modeCheck,
+		modeFrames,
+		modeImport,
+		modeMap,
+		modeGeotag,
+		modeXMP,
+		modeExif,
+		modeScans,
+		modeScaffold,
+		modeRename,
+	))
 	flag.StringVar(&format, "f", formatPretty, fmt.Sprintf("Format: %s or %s", formatPlain, formatPretty))
-	flag.StringVar(&conf.Separator, "s", conf.Separator, "Table column seperator")
-	flag.BoolVar(&md, "md", false, fmt.Sprintf("Output markdown compatible table (implies -f %s, ignores -s)", formatPlain))
+	flag.StringVar(
+		&output,
+		"o",
+		outputTerminal,
+		fmt.Sprintf(
+			"Output: %s, %s, %s, %s, %s, %s, %s (-m %s), %s (-m %s), %s or %s (-m %s)",
+			outputTerminal,
+			outputMarkdown,
+			outputHTML,
+			outputCSV,
+			outputAsciiDoc,
+			outputOrg,
+			outputJSON,
+			modeStats,
+			outputSVG,
+			modeTimeline,
+			outputGeoJSON,
+			outputGPX,
+			modeMap,
+		),
+	)
+	flag.StringVar(&sep, "s", " \u2502 ", fmt.Sprintf("Table column seperator (-o %s)", outputTerminal))
+	flag.BoolVar(&md, "md", false, fmt.Sprintf("Alias for -o %s", outputMarkdown))
 	flag.BoolVar(&nh, "nh", false, "Don't output header")
 	flag.StringVar(&id, "id", "", "Only show film roll with the given id")
+	flag.StringVar(&attrs, "attr", "", fmt.Sprintf(
+		"Only show film rolls with the given key=value attributes, comma separated (-m %s or %s)",
+		modeLog,
+		modeTags,
+	))
+	flag.StringVar(&near, "near", "", fmt.Sprintf(
+		"Only show film rolls with coordinates within lat,lon,km (-m %s, %s, %s or %s)",
+		modeLog,
+		modeTags,
+		modeFrames,
+		modeMap,
+	))
+	flag.StringVar(&attrCols, "cols", "", fmt.Sprintf("Show the given attributes as extra columns, comma separated (-m %s)", modeLog))
+	flag.StringVar(&groupBy, "group-by", "", fmt.Sprintf(
+		"Group rows and print subtotals, by: %s (-m %s) or %s (-m %s)",
+		groupList(db.LogGroups),
+		modeLog,
+		groupList(db.StockGroups),
+		modeStock,
+	))
+	flag.StringVar(&chart, "chart", "", fmt.Sprintf(
+		"Only draw the given chart (-m %s): %s",
+		modeChart,
+		chartList(),
+	))
+	flag.StringVar(&at, "at", "", fmt.Sprintf(
+		"Show the state as of the end of the given date (-m %s, %s or %s)",
+		modeLog,
+		modeStock,
+		modeShopping,
+	))
+	flag.IntVar(&expiryMonths, "expiry", 3, fmt.Sprintf("Warn about rolls expiring within the given amount of months (-m %s)", modeStock))
+	flag.StringVar(&importFile, "import", "", fmt.Sprintf("Json or csv frame log export to import (-m %s)", modeImport))
+	flag.BoolVar(&dryRun, "n", false, fmt.Sprintf(
+		"Dry run, only report what would be changed (-m %s, %s, %s, %s, %s or %s)",
+		modeImport,
+		modeGeotag,
+		modeXMP,
+		modeExif,
+		modeScaffold,
+		modeRename,
+	))
+	flag.StringVar(&scanDir, "dir", "", fmt.Sprintf(
+		"Directory with the scans of the roll given by -id (-m %s, %s or %s) or scan root overriding the config (-m %s or %s)",
+		modeXMP,
+		modeExif,
+		modeRename,
+		modeScans,
+		modeScaffold,
+	))
+	flag.StringVar(&pattern, "pattern", "", fmt.Sprintf(
+		"Scan folder name pattern overriding the config, e.g. {scan} or {id}-{stock} (-m %s or %s) or scan file name pattern, default %s (-m %s)",
+		modeScans,
+		modeScaffold,
+		db.DefaultRenamePattern,
+		modeRename,
+	))
+	flag.StringVar(&undo, "undo", "", fmt.Sprintf("Undo the rename recorded in the given manifest (-m %s)", modeRename))
+	flag.StringVar(&xmpStyle, "xmp-style", string(db.XMPLightroom), fmt.Sprintf(
+		"Sidecar naming: %s (image.xmp) or %s (image.jpg.xmp) (-m %s)",
+		db.XMPLightroom,
+		db.XMPDarktable,
+		modeXMP,
+	))
+	flag.BoolVar(&overwrite, "overwrite", false, fmt.Sprintf("Replace existing sidecars (-m %s)", modeXMP))
+	flag.StringVar(&gpxFile, "gpx", "", fmt.Sprintf("GPX track to geotag rolls and frames with (-m %s)", modeGeotag))
+	flag.DurationVar(&gpxDiff, "gpx-diff", 15*time.Minute, fmt.Sprintf("Max time between a frame and a track point (-m %s)", modeGeotag))
+	flag.StringVar(&tz, "tz", "Local", fmt.Sprintf("Time zone of the times in the log (-m %s)", modeGeotag))
+	flag.StringVar(&configFile, "c", config.DefaultPath(), "Config file")
+	flag.StringVar(&theme, "theme", "", fmt.Sprintf(
+		"Color theme: %s, %s, %s or a theme from the config file",
+		db.ThemeDark,
+		db.ThemeLight,
+		db.ThemeMonochrome,
+	))
+	flag.StringVar(&color, "color", colorAuto, fmt.Sprintf(
+		"Color: %s, %s or %s (%s disables color when stdout is not a terminal or NO_COLOR is set)",
+		colorAuto,
+		colorAlways,
+		colorNever,
+		colorAuto,
+	))
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "%s <flags> [file]:\n", os.Args[0])
 		flag.PrintDefaults()
@@ -54,11 +248,29 @@ func main() {
 		os.Exit(1)
 	}
 
+	if color != colorAuto && color != colorAlways && color != colorNever {
+		fmt.Fprintf(os.Stderr, "invalid color '%s'\n", color)
+		os.Exit(1)
+	}
+
+	if md {
+		output = outputMarkdown
+	}
+
+	cfg, err := config.Load(configFile)
+	exit(err)
+	if theme == "" {
+		theme = cfg.Theme
+	}
+	conf.Theme, err = cfg.LookupTheme(theme)
+	exit(err)
+
+	term, termErr := console.ConsoleFromFile(os.Stdout)
 	termWidth := func() int {
-		if md {
+		if output != outputTerminal || termErr != nil {
 			return 0
 		}
-		s, err := console.Current().Size()
+		s, err := term.Size()
 		if err != nil {
 			return 0
 		}
@@ -75,36 +287,310 @@ func main() {
 		conf.Header = false
 	}
 
-	if md {
-		// conf.Header = true
-		conf.HeaderSep = true
-		conf.Separator = " | "
-		conf.StartEndWithSeperator = true
-		format = formatPlain
+	switch output {
+	case outputTerminal:
+		r := table.Terminal{Sep: sep}
+		if format == formatPretty {
+			r.JoinSep = " "
+		}
+		conf.Renderer = r
+		switch color {
+		case colorAuto:
+			conf.Color = format == formatPretty && termErr == nil && os.Getenv("NO_COLOR") == ""
+		case colorAlways:
+			conf.Color = true
+		}
+	case outputMarkdown:
+		conf.Renderer = table.Markdown{}
+	case outputHTML:
+		conf.Renderer = table.HTML{}
+	case outputCSV:
+		conf.Renderer = table.CSV{}
+	case outputAsciiDoc:
+		conf.Renderer = table.AsciiDoc{}
+	case outputOrg:
+		conf.Renderer = table.Org{}
+	case outputJSON:
+		if mode != modeStats {
+			fmt.Fprintf(os.Stderr, "-o %s is only supported by -m %s\n", outputJSON, modeStats)
+			os.Exit(1)
+		}
+	case outputSVG:
+		if mode != modeTimeline {
+			fmt.Fprintf(os.Stderr, "-o %s is only supported by -m %s\n", outputSVG, modeTimeline)
+			os.Exit(1)
+		}
+	case outputGeoJSON, outputGPX:
+		if mode != modeMap {
+			fmt.Fprintf(os.Stderr, "-o %s is only supported by -m %s\n", output, modeMap)
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "invalid output '%s'\n", output)
+		os.Exit(1)
 	}
 
-	conf.Color = format == formatPretty
-	conf.Pretty = conf.Color
+	if at != "" {
+		conf.At, err = time.Parse("2006-01-02", at)
+		exit(err)
+	}
+
+	conf.Filter, err = db.ParseAttrs(attrs)
+	exit(err)
+	if near != "" {
+		conf.Near, err = db.ParseNear(near)
+		exit(err)
+	}
+	if attrCols != "" {
+		conf.AttrColumns = strings.Split(attrCols, ",")
+	}
+
+	dbFile := flag.Arg(0)
+	if dbFile == "" {
+		dbFile = "./rolls.log"
+	}
 
-	var run func(db *db.DB, id string)
+	var run func(db *db.DB, id string) error
 	switch mode {
 	case modeLog:
 		conf.IDFilter = id
 		conf.Width = termWidth()
+		conf.GroupBy, err = db.ParseGroupBy(groupBy, db.LogGroups)
+		exit(err)
 
-		run = func(db *db.DB, id string) {
-			db.PrintTable(os.Stdout, conf)
+		run = func(db *db.DB, id string) error {
+			return db.PrintTable(os.Stdout, conf)
 		}
 
 	case modeStock:
 		conf.Width = termWidth()
-		run = func(db *db.DB, id string) {
-			db.PrintStock(os.Stdout, conf)
+		conf.GroupBy, err = db.ParseGroupBy(groupBy, db.StockGroups)
+		exit(err)
+		run = func(db *db.DB, id string) error {
+			if err := db.PrintStock(os.Stdout, conf); err != nil {
+				return err
+			}
+			now := conf.At
+			if now.IsZero() {
+				now = time.Now()
+			}
+			for _, w := range db.ShortageWarnings(now) {
+				fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+			}
+			for _, w := range db.ExpiryWarnings(now, expiryMonths) {
+				fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+			}
+			return nil
+		}
+
+	case modeStats:
+		run = func(db *db.DB, id string) error {
+			if output == outputJSON {
+				return db.PrintStatsJSON(os.Stdout)
+			}
+			return db.PrintStats(os.Stdout, conf)
+		}
+
+	case modeChart:
+		conf.Width = termWidth()
+		kind, err := db.ParseChart(chart)
+		exit(err)
+		run = func(db *db.DB, id string) error {
+			return db.PrintCharts(os.Stdout, conf, kind)
+		}
+
+	case modeTimeline:
+		conf.Width = termWidth()
+		run = func(db *db.DB, id string) error {
+			if output == outputSVG {
+				return db.PrintTimelineSVG(os.Stdout, 1200)
+			}
+			return db.PrintTimeline(os.Stdout, conf)
+		}
+
+	case modeCost:
+		run = func(db *db.DB, id string) error {
+			return db.PrintCosts(os.Stdout, conf)
+		}
+
+	case modeShopping:
+		conf.Width = termWidth()
+		run = func(db *db.DB, id string) error {
+			return db.PrintShopping(os.Stdout, conf, cfg.Thresholds())
+		}
+
+	case modeFrames:
+		conf.IDFilter = id
+		conf.Width = termWidth()
+		run = func(db *db.DB, id string) error {
+			return db.PrintFrames(os.Stdout, conf)
+		}
+
+	case modeImport:
+		if importFile == "" {
+			exit(fmt.Errorf("-m %s requires -import", modeImport))
+		}
+		run = func(d *db.DB, id string) error {
+			f, err := os.Open(importFile)
+			if err != nil {
+				return err
+			}
+			records, err := db.ReadExport(f, importFile)
+			f.Close()
+			if err != nil {
+				return err
+			}
+
+			l := d.ImportFrames(records)
+			if err := d.PrintImport(os.Stdout, l); err != nil {
+				return err
+			}
+			if dryRun {
+				return nil
+			}
+			return db.ImportRewrite(l).WriteFile(dbFile)
+		}
+
+	case modeMap:
+		conf.IDFilter = id
+		run = func(db *db.DB, id string) error {
+			if output == outputGPX {
+				return db.PrintGPX(os.Stdout, conf)
+			}
+			return db.PrintGeoJSON(os.Stdout, conf)
+		}
+
+	case modeGeotag:
+		if gpxFile == "" {
+			exit(fmt.Errorf("-m %s requires -gpx", modeGeotag))
+		}
+		loc, err := time.LoadLocation(tz)
+		exit(err)
+		run = func(d *db.DB, id string) error {
+			f, err := os.Open(gpxFile)
+			if err != nil {
+				return err
+			}
+			track, err := db.ReadGPX(f, loc)
+			f.Close()
+			if err != nil {
+				return err
+			}
+
+			l := d.Geotag(track, gpxDiff)
+			if err := d.PrintGeotag(os.Stdout, l); err != nil {
+				return err
+			}
+			if dryRun {
+				return nil
+			}
+			return db.GeotagRewrite(l).WriteFile(dbFile)
+		}
+
+	case modeXMP:
+		if scanDir == "" {
+			exit(fmt.Errorf("-m %s requires -dir", modeXMP))
+		}
+		style := db.XMPStyle(xmpStyle)
+		if style != db.XMPLightroom && style != db.XMPDarktable {
+			exit(fmt.Errorf("invalid xmp style '%s'", xmpStyle))
+		}
+		run = func(d *db.DB, id string) error {
+			l, err := d.WriteSidecars(id, scanDir, style, overwrite, dryRun)
+			if perr := db.PrintSidecars(os.Stdout, l); err == nil {
+				err = perr
+			}
+			return err
+		}
+
+	case modeExif:
+		if scanDir == "" {
+			exit(fmt.Errorf("-m %s requires -dir", modeExif))
+		}
+		run = func(d *db.DB, id string) error {
+			l, err := d.EmbedMetadata(id, scanDir, dryRun)
+			if perr := db.PrintEmbed(os.Stdout, l); err == nil {
+				err = perr
+			}
+			return err
+		}
+
+	case modeScans, modeScaffold:
+		if scanDir == "" {
+			scanDir = cfg.Scans.Root
+		}
+		if scanDir == "" {
+			exit(fmt.Errorf("-m %s requires -dir or a scan root in the config", mode))
+		}
+		p := cfg.ScanPattern()
+		if pattern != "" {
+			p = db.Pattern(pattern)
+		}
+		conf.Width = termWidth()
+		run = func(d *db.DB, id string) error {
+			s, err := d.Scans(scanDir, p)
+			if err != nil {
+				return err
+			}
+			if mode == modeScans {
+				return s.Print(os.Stdout, conf)
+			}
+			l, err := s.Scaffold(dryRun)
+			if perr := db.PrintScaffold(os.Stdout, s, l); err == nil {
+				err = perr
+			}
+			return err
+		}
+
+	case modeRename:
+		if scanDir == "" && undo == "" {
+			exit(fmt.Errorf("-m %s requires -dir or -undo", modeRename))
+		}
+		p := db.DefaultRenamePattern
+		if pattern != "" {
+			p = db.Pattern(pattern)
+		}
+		run = func(d *db.DB, id string) error {
+			var plan db.RenamePlan
+			var manifest string
+			if undo != "" {
+				r, err := db.ReadRenameManifest(undo)
+				if err != nil {
+					return err
+				}
+				plan = r.Undo()
+			} else {
+				var err error
+				if plan, err = d.RenameScans(id, scanDir, p); err != nil {
+					return err
+				}
+				manifest = plan.ManifestPath(time.Now().Format("20060102-150405"))
+			}
+
+			if err := db.PrintRenames(os.Stdout, plan); err != nil {
+				return err
+			}
+			if dryRun {
+				return nil
+			}
+			if err := plan.Apply(manifest); err != nil {
+				return err
+			}
+			if manifest != "" && len(plan.Renames) != 0 {
+				fmt.Printf("undo with -m %s -undo %s\n", modeRename, manifest)
+			}
+			return nil
+		}
+
+	case modeCheck:
+		run = func(db *db.DB, id string) error {
+			return db.PrintCheck(os.Stdout)
 		}
 
 	case modeTags:
-		run = func(db *db.DB, id string) {
-			db.PrintTags(os.Stdout, id)
+		run = func(db *db.DB, id string) error {
+			db.PrintTags(os.Stdout, id, conf.Filter, conf.Near)
+			return nil
 		}
 
 	default:
@@ -112,11 +598,6 @@ func main() {
 		os.Exit(1)
 	}
 
-	dbFile := flag.Arg(0)
-	if dbFile == "" {
-		dbFile = "./rolls.log"
-	}
-
 	if verbose {
 		fmt.Fprintf(os.Stderr, "Opening %s\n", dbFile)
 	}
@@ -128,7 +609,7 @@ func main() {
 	f.Close()
 	exit(err)
 
-	run(db, id)
+	exit(run(db, id))
 
 	if verbose {
 		fmt.Fprintln(os.Stderr, time.Since(bench))
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/frizinak/film-rolls/db"
+)
+
+type Config struct {
+	// Theme is the name of a custom or builtin theme.
+	Theme string `json:"theme"`
+	// Themes defines custom themes by name.
+	Themes map[string]db.Theme `json:"themes"`
+	// Reorder is the reorder threshold of a stock by stock id.
+	Reorder map[string]int `json:"reorder"`
+	// Scans configures where the scans of developed rolls are kept.
+	Scans Scans `json:"scans"`
+}
+
+type Scans struct {
+	// Root is the directory containing a folder per roll.
+	Root string `json:"root"`
+	// Pattern is the folder name of a roll, e.g. "{scan}" or "{id}".
+	Pattern db.Pattern `json:"pattern"`
+}
+
+func Default() Config {
+	return Config{Theme: db.ThemeDark}
+}
+
+// DefaultPath returns the path of config.json in the user's config
+// directory.
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "film-rolls", "config.json")
+}
+
+// Load reads the json config file at path, a missing or empty file results
+// in the default config.
+func Load(path string) (Config, error) {
+	c := Default()
+	if path == "" {
+		return c, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return c, nil
+		}
+		return c, err
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return c, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+// LookupTheme returns the custom or builtin theme with the given name.
+func (c Config) LookupTheme(name string) (db.Theme, error) {
+	if t, ok := c.Themes[name]; ok {
+		return t, nil
+	}
+	if t, ok := db.BuiltinTheme(name); ok {
+		return t, nil
+	}
+	return db.Theme{}, fmt.Errorf("no such theme '%s'", name)
+}
+
+// Thresholds returns the reorder thresholds by stock id.
+func (c Config) Thresholds() map[db.ID]int {
+	m := make(map[db.ID]int, len(c.Reorder))
+	for id, n := range c.Reorder {
+		m[db.ID(id)] = n
+	}
+	return m
+}
+
+// ScanPattern returns the configured scan folder pattern or the default.
+func (c Config) ScanPattern() db.Pattern {
+	if c.Scans.Pattern == "" {
+		return db.DefaultScanPattern
+	}
+	return c.Scans.Pattern
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Attrs are free form key=value attributes of an entry.
+type Attrs map[string]string
+
+// ParseAttrs parses a comma separated list of key=value pairs.
+func ParseAttrs(str string) (Attrs, error) {
+	a := make(Attrs)
+	if str == "" {
+		return a, nil
+	}
+	for _, kv := range strings.Split(str, ",") {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			return a, fmt.Errorf("invalid attribute '%s', expected key=value", kv)
+		}
+		a[k] = v
+	}
+	return a, nil
+}
+
+// Match reports whether a has all attributes of filter.
+func (a Attrs) Match(filter Attrs) bool {
+	for k, v := range filter {
+		if av, ok := a[k]; !ok || av != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Keys returns the sorted attribute keys.
+func (a Attrs) Keys() []string {
+	l := make([]string, 0, len(a))
+	for k := range a {
+		l = append(l, k)
+	}
+	slices.Sort(l)
+	return l
+}
+
+// trailing splits an entry line in its positional fields and the @[ei] and
+// key=value tokens that follow them.
+func trailing(p []string) ([]string, []string) {
+	for i, tok := range p {
+		if strings.HasPrefix(tok, "@") || strings.Contains(tok, "=") {
+			return p[:i], p[i:]
+		}
+	}
+	return p, nil
+}
+
+// parseAttrs parses the trailing key=value tokens that are left after the
+// typed tokens were extracted.
+func parseAttrs(p []string) (Attrs, error) {
+	a := make(Attrs)
+	for _, tok := range p {
+		k, v, ok := strings.Cut(tok, "=")
+		if !ok || k == "" {
+			return a, fmt.Errorf("invalid attribute '%s', expected key=value", tok)
+		}
+		if _, ok := a[k]; ok {
+			return a, fmt.Errorf("duplicate attribute '%s'", k)
+		}
+		a[k] = v
+	}
+	return a, nil
+}
@@ -0,0 +1,161 @@
+package db
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/frizinak/film-rolls/chart"
+)
+
+type Chart string
+
+const (
+	ChartAll       Chart = ""
+	ChartMonth     Chart = "month"
+	ChartStock     Chart = "stock"
+	ChartCamera    Chart = "camera"
+	ChartSparkline Chart = "sparkline"
+	ChartHeatmap   Chart = "heatmap"
+)
+
+var Charts = []Chart{ChartMonth, ChartStock, ChartCamera, ChartSparkline, ChartHeatmap}
+
+func ParseChart(str string) (Chart, error) {
+	c := Chart(str)
+	if c != ChartAll && !slices.Contains(Charts, c) {
+		l := make([]string, len(Charts))
+		for i := range Charts {
+			l[i] = string(Charts[i])
+		}
+		return c, fmt.Errorf("invalid chart '%s', expected one of: %s", str, strings.Join(l, ", "))
+	}
+	return c, nil
+}
+
+// monthly returns the number of rolls loaded per month, including months
+// without any, from the first to the last month in the log.
+func (db *DB) monthly() ([]time.Time, []int) {
+	if len(db.Entries) == 0 {
+		return nil, nil
+	}
+
+	month := func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	first, last := month(db.Entries[0].LoadDate), month(db.Entries[0].LoadDate)
+	counts := make(map[time.Time]int)
+	for _, e := range db.Entries {
+		m := month(e.LoadDate)
+		counts[m]++
+		if m.Before(first) {
+			first = m
+		}
+		if m.After(last) {
+			last = m
+		}
+	}
+
+	months := make([]time.Time, 0)
+	values := make([]int, 0)
+	for m := first; !m.After(last); m = m.AddDate(0, 1, 0) {
+		months = append(months, m)
+		values = append(values, counts[m])
+	}
+
+	return months, values
+}
+
+func (db *DB) PrintCharts(w io.Writer, conf TableConfig, kind Chart) error {
+	width := conf.Width
+	if width == 0 {
+		width = 80
+	}
+
+	var style chart.Style
+	if conf.Color {
+		style = chart.Style{Prefix: conf.Theme.Stock.Prefix(), Suffix: conf.Theme.Stock.Suffix()}
+	}
+
+	bars := func(l []Count) []chart.Bar {
+		b := make([]chart.Bar, len(l))
+		for i := range l {
+			b[i] = chart.Bar{Label: l[i].Key, Value: l[i].Rolls}
+		}
+		return b
+	}
+
+	s := db.Stats()
+	first := true
+	section := func(c Chart, title string, draw func() error) error {
+		if kind != ChartAll && kind != c {
+			return nil
+		}
+		if !first {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		first = false
+		if conf.Header {
+			if _, err := fmt.Fprintln(w, title); err != nil {
+				return err
+			}
+		}
+		return draw()
+	}
+
+	months, values := db.monthly()
+	sections := []struct {
+		Chart
+		title string
+		draw  func() error
+	}{
+		{ChartMonth, "Rolls per month", func() error {
+			l := make([]chart.Bar, len(months))
+			for i := range months {
+				l[i] = chart.Bar{Label: months[i].Format("2006-01"), Value: values[i]}
+			}
+			return chart.Bars(w, l, width, style)
+		}},
+		{ChartStock, "Rolls per stock", func() error {
+			return chart.Bars(w, bars(s.PerStock), width, style)
+		}},
+		{ChartCamera, "Rolls per camera", func() error {
+			return chart.Bars(w, bars(s.PerCamera), width, style)
+		}},
+		{ChartSparkline, "Monthly activity", func() error {
+			if len(months) == 0 {
+				return nil
+			}
+			spark := chart.Sparkline(values, width-16)
+			from := months[len(months)-len([]rune(spark))]
+			_, err := fmt.Fprintf(
+				w,
+				"%s %s %s\n",
+				from.Format("2006-01"),
+				style.Prefix+spark+style.Suffix,
+				months[len(months)-1].Format("2006-01"),
+			)
+			return err
+		}},
+		{ChartHeatmap, "Load dates", func() error {
+			counts := make(map[time.Time]int, len(db.Entries))
+			for _, e := range db.Entries {
+				counts[e.LoadDate]++
+			}
+			return chart.Heatmap(w, counts, width, style)
+		}},
+	}
+
+	for _, sec := range sections {
+		if err := section(sec.Chart, sec.title, sec.draw); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
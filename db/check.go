@@ -0,0 +1,73 @@
+package db
+
+import (
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Problem is a possible mistake in the log.
+type Problem struct {
+	Line uint
+	Msg  string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("line %d: %s", p.Line, p.Msg)
+}
+
+// Check returns the problems found in the log in order of appearance.
+func (db *DB) Check() []Problem {
+	l := make([]Problem, 0)
+	for _, e := range db.Entries {
+		if e.EI != 0 && e.Push == 0 && !e.Stock.ISO.Contains(e.EI) {
+			l = append(l, Problem{e.Line, fmt.Sprintf(
+				"EI %d is outside the ISO range %s of %s without a push or pull",
+				e.EI,
+				e.Stock.ISO,
+				e.Stock.String(),
+			)})
+		}
+		lenses := slices.Clone(e.Lenses)
+		for _, f := range e.Frames {
+			if f.Lens != nil && !slices.Contains(lenses, f.Lens) {
+				lenses = append(lenses, f.Lens)
+			}
+		}
+		for _, lens := range lenses {
+			if !e.Camera.Fits(lens) {
+				l = append(l, Problem{e.Line, fmt.Sprintf(
+					"%s (%s mount) doesn't fit %s (%s mount)",
+					lens.String(),
+					lens.Mount,
+					e.Camera.String(),
+					e.Camera.Mount,
+				)})
+			}
+		}
+		if !e.Lab.None() && !e.Lab.Supports(e.Stock.Process) {
+			l = append(l, Problem{e.Line, fmt.Sprintf(
+				"%s needs %s which %s doesn't list as supported",
+				e.Stock.String(),
+				e.Stock.Process,
+				e.Lab.String(),
+			)})
+		}
+	}
+
+	return l
+}
+
+// PrintCheck prints all problems and returns an error if there were any.
+func (db *DB) PrintCheck(w io.Writer) error {
+	l := db.Check()
+	for _, p := range l {
+		if _, err := fmt.Fprintf(w, "warning: %s\n", p); err != nil {
+			return err
+		}
+	}
+	if len(l) != 0 {
+		return fmt.Errorf("%d problem(s) found", len(l))
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+package db
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/frizinak/film-rolls/table"
+)
+
+// Prices is a sum of prices in different currencies.
+type Prices map[string]int64
+
+func (p Prices) Add(price Price) {
+	if price.Zero() {
+		return
+	}
+	p[price.Currency] += price.Cents
+}
+
+func (p Prices) AddAll(o Prices) {
+	for c, v := range o {
+		p[c] += v
+	}
+}
+
+// Avg divides each amount by the number of rolls with a cost in its
+// currency.
+func (p Prices) Avg(rolls Rolls) Prices {
+	d := make(Prices, len(p))
+	for c, v := range p {
+		if n := rolls[c]; n != 0 {
+			d[c] = v / int64(n)
+		}
+	}
+	return d
+}
+
+// Rolls is a number of rolls by currency.
+type Rolls map[string]int
+
+// Add counts n rolls for each currency in p.
+func (r Rolls) Add(p Prices, n int) {
+	for c := range p {
+		r[c] += n
+	}
+}
+
+func (p Prices) String() string {
+	cur := make([]string, 0, len(p))
+	for c := range p {
+		cur = append(cur, c)
+	}
+	slices.Sort(cur)
+
+	l := make([]string, 0, len(p))
+	for _, c := range cur {
+		l = append(l, Price{p[c], c}.String())
+	}
+	return strings.Join(l, " + ")
+}
+
+// LabCosts are the costs of a single lab visit.
+type LabCosts struct {
+	Develop  Price
+	Scan     Price
+	Shipping Price
+}
+
+func (l LabCosts) Zero() bool { return l == LabCosts{} }
+
+func (l LabCosts) Prices() Prices {
+	p := make(Prices, 1)
+	p.Add(l.Develop)
+	p.Add(l.Scan)
+	p.Add(l.Shipping)
+	return p
+}
+
+const (
+	costDevelop  = "develop"
+	costScan     = "scan"
+	costShipping = "shipping"
+)
+
+// parseCosts extracts develop=[price], scan=[price] and shipping=[price]
+// tokens from an entry line.
+func parseCosts(p []string) ([]string, LabCosts, error) {
+	var c LabCosts
+	rest := make([]string, 0, len(p))
+	for _, tok := range p {
+		k, v, ok := strings.Cut(tok, "=")
+		var dst *Price
+		switch k {
+		case costDevelop:
+			dst = &c.Develop
+		case costScan:
+			dst = &c.Scan
+		case costShipping:
+			dst = &c.Shipping
+		}
+		if !ok || dst == nil {
+			rest = append(rest, tok)
+			continue
+		}
+
+		price, err := ParsePrice(v)
+		if err != nil {
+			return p, c, fmt.Errorf("%s cost: %w", k, err)
+		}
+		*dst = price
+	}
+
+	return rest, c, nil
+}
+
+type CostRow struct {
+	Key string
+	// Rolls is the number of rolls with a cost.
+	Rolls int
+	// Priced is the number of rolls with a cost by currency.
+	Priced Rolls
+	Stock  Prices
+	Lab    Prices
+}
+
+func (c CostRow) Total() Prices {
+	p := make(Prices, len(c.Stock)+len(c.Lab))
+	p.AddAll(c.Stock)
+	p.AddAll(c.Lab)
+	return p
+}
+
+type Costs struct {
+	PerMonth []CostRow
+	PerYear  []CostRow
+	PerStock []CostRow
+	PerLab   []CostRow
+
+	// PerRoll is the average all-in cost of a shot roll per currency: the
+	// average price paid per roll of its stock and its lab costs, over the
+	// rolls with a cost in that currency.
+	PerRoll Prices
+}
+
+func (db *DB) Costs() Costs {
+	var c Costs
+	rows := func() (map[string]*CostRow, func(string) *CostRow) {
+		m := make(map[string]*CostRow)
+		return m, func(k string) *CostRow {
+			if _, ok := m[k]; !ok {
+				m[k] = &CostRow{Key: k, Priced: make(Rolls), Stock: make(Prices), Lab: make(Prices)}
+			}
+			return m[k]
+		}
+	}
+	sorted := func(m map[string]*CostRow) []CostRow {
+		l := make([]CostRow, 0, len(m))
+		for _, r := range m {
+			l = append(l, *r)
+		}
+		slices.SortFunc(l, func(a, b CostRow) int { return cmp.Compare(a.Key, b.Key) })
+		return l
+	}
+
+	months, month := rows()
+	years, year := rows()
+	stocks, stock := rows()
+	labs, lab := rows()
+
+	for _, b := range db.Purchases {
+		if b.Price.Zero() {
+			continue
+		}
+		month(b.Date.Format(monthFormat)).Stock.Add(b.Price)
+		year(b.Date.Format("2006")).Stock.Add(b.Price)
+		r := stock(b.Stock.String())
+		r.Stock.Add(b.Price)
+		r.Rolls += b.Rolls
+		r.Priced[b.Price.Currency] += b.Rolls
+	}
+
+	total := make(Prices)
+	known := make(Rolls)
+	for _, e := range db.Entries {
+		all := make(Prices)
+		if r, ok := stocks[e.Stock.String()]; ok {
+			all.AddAll(r.Stock.Avg(r.Priced))
+		}
+		p := e.Costs.Prices()
+		all.AddAll(p)
+		total.AddAll(all)
+		known.Add(all, 1)
+		if e.Costs.Zero() {
+			continue
+		}
+
+		d := e.LabInDate
+		if d.IsZero() {
+			d = e.LoadDate
+		}
+		month(d.Format(monthFormat)).Lab.AddAll(p)
+		year(d.Format("2006")).Lab.AddAll(p)
+		r := lab(e.Lab.String())
+		r.Rolls++
+		r.Priced.Add(p, 1)
+		r.Lab.AddAll(p)
+	}
+
+	c.PerMonth = sorted(months)
+	c.PerYear = sorted(years)
+	c.PerStock = sorted(stocks)
+	c.PerLab = sorted(labs)
+	c.PerRoll = total.Avg(known)
+
+	return c
+}
+
+func (db *DB) CostTables(conf TableConfig) []*table.Table {
+	c := db.Costs()
+	style := conf.style
+	tables := make([]*table.Table, 0, 5)
+
+	price := func(p Prices) table.Col {
+		return table.ColFixed(table.ColAlignRight(table.TermStr(p.String())))
+	}
+
+	spend := func(title string, l []CostRow) {
+		t := table.New()
+		if conf.Header {
+			for _, h := range []string{title, "Stock", "Lab", "Total"} {
+				t.AddHeadCol(table.TermStr(h))
+			}
+		}
+		for _, r := range l {
+			t.AddRow(
+				table.ColFixed(table.TermStr(r.Key)),
+				price(r.Stock),
+				price(r.Lab),
+				price(r.Total()),
+			)
+		}
+		tables = append(tables, t)
+	}
+
+	perRoll := func(title string, l []CostRow, p func(CostRow) Prices) {
+		t := table.New()
+		if conf.Header {
+			for _, h := range []string{title, "Rolls", "Spent", "Per roll"} {
+				t.AddHeadCol(table.TermStr(h))
+			}
+		}
+		for _, r := range l {
+			t.AddRow(
+				table.ColFixed(style(table.TermStr(r.Key), conf.Theme.Stock)),
+				table.ColFixed(table.ColAlignRight(table.TermStr(strconv.Itoa(r.Rolls)))),
+				price(p(r)),
+				price(p(r).Avg(r.Priced)),
+			)
+		}
+		tables = append(tables, t)
+	}
+
+	t := table.New()
+	if conf.Header {
+		t.AddHeadCol(table.TermStr("Summary"))
+		t.AddHeadCol(table.TermStr(""))
+	}
+	t.AddRow(
+		table.ColFixed(table.TermStr("Average all-in cost per roll")),
+		price(c.PerRoll),
+	)
+	tables = append(tables, t)
+
+	spend("Year", c.PerYear)
+	spend("Month", c.PerMonth)
+	perRoll("Stock", c.PerStock, func(r CostRow) Prices { return r.Stock })
+	perRoll("Lab", c.PerLab, func(r CostRow) Prices { return r.Lab })
+
+	return tables
+}
+
+func (db *DB) PrintCosts(w io.Writer, conf TableConfig) error {
+	return conf.renderAll(w, db.CostTables(conf))
+}
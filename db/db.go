@@ -21,7 +21,7 @@ type Company struct {
 }
 
 func (c *Company) String() string {
-	return fmt.Sprintf("[%s] %s", c.ID, c.Name)
+	return fmt.Sprintf("%s %s", c.ID, c.Name)
 }
 
 func (c *Company) Short() string {
@@ -35,10 +35,15 @@ type Stock struct {
 	ISO     ISO
 	Format  string
 	Rolls   int
+	Type    FilmType
+	// Process defaults to the usual process of Type.
+	Process Process
+	// Reorder is the reorder threshold, zero if not set.
+	Reorder int
 }
 
 func (s *Stock) String() string {
-	return fmt.Sprintf("[%s] %s - %s %s %s", s.ID, s.Company.Short(), s.Name, s.Format, s.ISO)
+	return fmt.Sprintf("%s %s - %s %s %s", s.ID, s.Company.Short(), s.Name, s.Format, s.ISO)
 }
 
 func (s *Stock) Short() string {
@@ -57,18 +62,19 @@ func (iso ISO) String() string {
 }
 
 type Lab struct {
-	ID   ID
-	Name string
+	ID        ID
+	Name      string
+	Processes []Process
 }
 
-func LabNone() *Lab { return &Lab{ID0(), ""} }
+func LabNone() *Lab { return &Lab{ID: ID0()} }
 
 func (l *Lab) String() string {
 	if l.None() {
 		return "[N/A]"
 	}
 
-	return fmt.Sprintf("[%s] %s", l.ID, l.Name)
+	return fmt.Sprintf("%s %s", l.ID, l.Name)
 }
 
 func (l *Lab) None() bool {
@@ -85,10 +91,11 @@ type Camera struct {
 	ID    ID
 	Brand string
 	Model string
+	Mount string
 }
 
 func (c *Camera) String() string {
-	return fmt.Sprintf("[%s] %s %s", c.ID, c.Brand, c.Model)
+	return fmt.Sprintf("%s %s %s", c.ID, c.Brand, c.Model)
 }
 
 func (c *Camera) Short() string {
@@ -103,10 +110,27 @@ type Entry struct {
 	Stock  *Stock
 	Camera *Camera
 	Lab    *Lab
+	Lenses []*Lens
 
 	Scan uint
 
+	Costs LabCosts
+
+	// EI is the exposure index the roll was shot at, zero if unknown.
+	EI uint32
+	// Push is the amount of stops the roll is pushed, negative for a pull.
+	Push int
+
+	Attrs Attrs
+
+	Location string
+	Geo      *Point
+
+	Frames []Frame
+
 	Line uint
+	// End is the last line of the entry, its notes and frames.
+	End uint
 
 	Note string
 }
@@ -131,11 +155,13 @@ func MkID(str string) (ID, error) {
 }
 
 type DB struct {
-	Entries []Entry
+	Entries   []Entry
+	Purchases []Purchase
 
 	Companies map[ID]*Company
 	Stocks    map[ID]*Stock
 	Cameras   map[ID]*Camera
+	Lenses    map[ID]*Lens
 	Labs      map[ID]*Lab
 }
 
@@ -172,161 +198,106 @@ func (db *DB) row(idFilter string, row func(e Entry, id string, active bool)) {
 
 type TableConfig struct {
 	IDFilter string
+	// Filter only shows entries with all of the given attributes.
+	Filter Attrs
+	// AttrColumns are the attributes shown as extra columns.
+	AttrColumns []string
+	// Near only shows entries with coordinates within the given area.
+	Near *Near
 
 	Color  bool
-	Pretty bool
+	Theme  Theme
+	Header bool
+
+	Renderer table.Renderer
 
-	Header                bool
-	HeaderSep             bool
-	Separator             string
-	StartEndWithSeperator bool
+	GroupBy GroupBy
+
+	// At shows the state as of the end of the given day.
+	At time.Time
 
 	Width int
 }
 
 var defaultConf = TableConfig{
-	Separator: " \u2502 ",
+	Theme:    themes[ThemeDark],
+	Renderer: table.Terminal{Sep: " \u2502 "},
 }
 
 func TableConfigDefault() TableConfig { return defaultConf }
 
-func (db *DB) PrintTable(w io.Writer, conf TableConfig) {
-	t := table.New()
-	space := table.TermStr(" ")
-	line := table.TermStr(conf.Separator)
-	lline := table.TermStr(strings.TrimLeft(conf.Separator, " "))
-	rline := table.TermStr(strings.TrimRight(conf.Separator, " "))
-
-	if !conf.Pretty {
-		space = line
+func (conf TableConfig) render(w io.Writer, t *table.Table) error {
+	if conf.Width != 0 {
+		t.SetFixedWidth(conf.Width)
 	}
-
-	clr := func(seq string) string {
-		if conf.Color {
-			return seq
-		}
-		return ""
+	r := conf.Renderer
+	if r == nil {
+		r = defaultConf.Renderer
 	}
+	return r.Render(w, t)
+}
 
-	row := func(
-		active bool,
-		activeString,
-		id,
-		date,
-		cameraID, cameraBrand, cameraModel,
-		stockID, stockName, stockFormat, stockISO, stockCompany,
-		labID, labName, labInDate, labOutDate,
-		scan, note, linenr string,
-	) {
-		t.NewRow()
-		if conf.StartEndWithSeperator {
-			t.AddCol(table.ColFixed(lline))
+// renderAll renders each table separated by an empty line.
+func (conf TableConfig) renderAll(w io.Writer, tables []*table.Table) error {
+	for i, t := range tables {
+		if i != 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
 		}
-
-		t.AddCol(table.ColFixed(table.TermStr(date)))
-		t.AddCol(table.ColFixed(line))
-
-		t.AddCol(table.ColFixed(table.TermStr(id)))
-		t.AddCol(table.ColFixed(line))
-
-		t.AddCol(table.ColFixed(table.ColPreSuf(
-			table.TermStr(cameraID),
-			clr("\033[38;5;244m"),
-			clr("\033[0m"),
-		)))
-
-		t.AddCol(table.ColFixed(space))
-		camPrefix, camSuffix := "", ""
-		if active && conf.Color {
-			camPrefix = "\033[31m"
-			camSuffix = "\033[0m"
+		if err := conf.render(w, t); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		t.AddCol(table.ColFixed(table.ColPreSuf(table.TermStr(cameraBrand), camPrefix, camSuffix)))
-		t.AddCol(table.ColFixed(space))
-		t.AddCol(table.ColFixed(table.ColPreSuf(table.TermStr(cameraModel), camPrefix, camSuffix)))
-		t.AddCol(table.ColFixed(line))
-
-		if !conf.Color {
-			t.AddCol(table.ColFixed(table.TermStr(activeString)))
-			t.AddCol(table.ColFixed(line))
-		}
-
-		t.AddCol(table.ColFixed(table.ColPreSuf(
-			table.TermStr(stockID),
-			clr("\033[38;5;244m"),
-			clr("\033[0m"),
-		)))
-		t.AddCol(table.ColFixed(space))
-		t.AddCol(table.ColFixed(table.ColPreSuf(
-			table.TermStr(stockCompany),
-			clr("\033[32m"),
-			clr("\033[0m"),
-		)))
-		t.AddCol(table.ColFixed(space))
-		t.AddCol(table.ColFixed(table.ColPreSuf(
-			table.TermStr(stockName),
-			clr("\033[32m"),
-			clr("\033[0m"),
-		)))
-		t.AddCol(table.ColFixed(space))
-		t.AddCol(table.ColAlignRight(table.ColFixed(table.TermStr(stockFormat))))
-		t.AddCol(table.ColFixed(space))
-		t.AddCol(table.ColAlignRight(table.ColFixed(table.TermStr(stockISO))))
-		t.AddCol(table.ColFixed(line))
-
-		t.AddCol(table.ColFixed(table.ColPreSuf(
-			table.TermStr(labID),
-			clr("\033[38;5;244m"),
-			clr("\033[0m"),
-		)))
-		t.AddCol(table.ColFixed(space))
-		t.AddCol(table.ColFixed(table.TermStr(labName)))
-		t.AddCol(table.ColFixed(space))
-		t.AddCol(table.ColFixed(table.TermStr(labInDate)))
-		t.AddCol(table.ColFixed(space))
-		t.AddCol(table.ColFixed(table.TermStr(labOutDate)))
-		t.AddCol(table.ColFixed(line))
-
-		t.AddCol(table.ColFixed(table.TermStr(scan)))
-		t.AddCol(table.ColFixed(line))
-		t.AddCol(table.ColFixed(table.TermStr(linenr)))
-		t.AddCol(table.ColFixed(line))
-		t.AddCol(table.TermStr(note))
-
-		if conf.StartEndWithSeperator {
-			t.AddCol(table.ColFixed(rline))
+func (conf TableConfig) filter(row func(e Entry, id string, active bool)) func(e Entry, id string, active bool) {
+	return func(e Entry, id string, active bool) {
+		if e.Attrs.Match(conf.Filter) && conf.Near.Match(e) {
+			row(e, id, active)
 		}
 	}
+}
+
+func (conf TableConfig) style(col table.Col, s Style) table.Col {
+	if !conf.Color || s == "" {
+		return col
+	}
+	return table.ColPreSuf(col, s.Prefix(), s.Suffix())
+}
+
+func (db *DB) LogTable(conf TableConfig) *table.Table {
+	db = db.At(conf.At)
+	t := table.New()
+	style := conf.style
 
 	if conf.Header {
-		row(
-			false,
-			"Active",
-			"ID",
+		for _, h := range []string{
 			"Date",
+			"ID",
 			"[CID]", "Brand", "Model",
-			"[SID]", "Stock", "Format", "ISO", "Manufacturer",
+		} {
+			t.AddHeadCol(table.TermStr(h))
+		}
+		if !conf.Color {
+			t.AddHeadCol(table.TermStr("Active"))
+		}
+		for _, h := range []string{
+			"Lenses",
+			"[SID]", "Manufacturer", "Stock", "Format", "ISO", "EI",
 			"[LID]", "Lab Name", "Lab in", "Lab out",
-			"Scan", "Note", "Line",
-		)
-	}
-
-	if conf.HeaderSep {
-		hs := ":---"
-		row(
-			false,
-			hs,
-			hs,
-			hs,
-			hs, hs, hs,
-			hs, hs, hs, hs, hs,
-			hs, hs, hs, hs,
-			hs, hs, hs,
-		)
+			"Scan", "Line", "Location",
+		} {
+			t.AddHeadCol(table.TermStr(h))
+		}
+		for _, h := range conf.AttrColumns {
+			t.AddHeadCol(table.TermStr(h))
+		}
+		t.AddHeadCol(table.TermStr("Note"))
 	}
 
-	db.row(conf.IDFilter, func(e Entry, id string, active bool) {
+	add := func(e Entry, id string, active bool) {
 		var labName, labInDate, labOutDate string
 		labID := "[N/A]"
 		if !e.Lab.None() {
@@ -343,160 +314,322 @@ func (db *DB) PrintTable(w io.Writer, conf TableConfig) {
 		if e.Scan != 0 {
 			scan = fmt.Sprintf("%04d", e.Scan)
 		}
-		activeString := " "
-		if active {
-			activeString = "loaded"
-		}
-		row(
-			active,
-			activeString,
-			id,
-			e.LoadDate.Format(dateFormat),
-			e.Camera.ID.String(), e.Camera.Brand, e.Camera.Model,
-			e.Stock.ID.String(), e.Stock.Name, e.Stock.Format, e.Stock.ISO.String(), e.Stock.Company.Name,
-			labID, labName, labInDate, labOutDate,
-			scan, e.Note, fmt.Sprintf("%d", e.Line),
-		)
-	})
-	if conf.Width != 0 {
-		t.SetFixedWidth(conf.Width)
-	}
-	t.WriteTo(w, "")
-}
 
-func (db *DB) PrintTags(w io.Writer, idFilter string) {
-	r := strings.NewReplacer(" ", "_")
-	clean := func(str string) string {
-		return strings.ToLower(r.Replace(str))
-	}
+		t.NewRow()
+		t.AddCol(table.ColFixed(table.TermStr(e.LoadDate.Format(dateFormat))))
+		t.AddCol(table.ColFixed(table.TermStr(id)))
 
-	list := make([]string, 0, 6)
-	db.row(idFilter, func(e Entry, id string, active bool) {
-		list = list[:0]
-		list = append(list, fmt.Sprintf("id:%s", id))
-		list = append(list, fmt.Sprintf("camera:%s-%s", clean(e.Camera.Brand), clean(e.Camera.Model)))
-		list = append(list, fmt.Sprintf("film:%s-%s", clean(e.Stock.Company.Name), clean(e.Stock.Name)))
-		list = append(list, fmt.Sprintf("iso:%s", clean(e.Stock.ISO.String())))
-		if !e.Lab.None() {
-			list = append(list, fmt.Sprintf("lab:%s", clean(e.Lab.Name)))
+		t.AddCol(table.ColFixed(style(table.TermStr(e.Camera.ID.String()), conf.Theme.ID)))
+		camStyle := conf.Theme.Camera
+		if active {
+			camStyle = conf.Theme.Active
 		}
-		if e.Scan != 0 {
-			list = append(list, fmt.Sprintf("scan:%04d", e.Scan))
+		t.AddCol(table.ColJoined(table.ColFixed(style(table.TermStr(e.Camera.Brand), camStyle))))
+		t.AddCol(table.ColJoined(table.ColFixed(style(table.TermStr(e.Camera.Model), camStyle))))
+
+		atLab := !active && !e.Lab.None() && !e.LabInDate.IsZero() && e.LabOutDate.IsZero()
+		if !conf.Color {
+			activeString := " "
+			if active {
+				activeString = "loaded"
+			} else if atLab {
+				activeString = "at lab"
+			}
+			t.AddCol(table.ColFixed(table.TermStr(activeString)))
 		}
-		list = append(list, fmt.Sprintf("line:%d", e.Line))
 
-		fmt.Fprintln(w, strings.Join(list, " "))
-	})
-}
+		lenses := make([]string, len(e.Lenses))
+		for i, l := range e.Lenses {
+			lenses[i] = l.ID.String()
+		}
+		t.AddCol(table.ColFixed(style(table.TermStr(strings.Join(lenses, " ")), conf.Theme.ID)))
+
+		t.AddCol(table.ColFixed(style(table.TermStr(e.Stock.ID.String()), conf.Theme.ID)))
+		t.AddCol(table.ColJoined(table.ColMinWidth(table.ColFixed(style(table.TermStr(e.Stock.Company.Name), conf.Theme.Stock)), 5)))
+		t.AddCol(table.ColJoined(table.ColMinWidth(table.ColFixed(style(table.TermStr(e.Stock.Name), conf.Theme.Stock)), 8)))
+		t.AddCol(table.ColJoined(table.ColAlignRight(table.ColFixed(table.TermStr(e.Stock.Format)))))
+		t.AddCol(table.ColJoined(table.ColAlignRight(table.ColFixed(table.TermStr(e.Stock.ISO.String())))))
+		var ei table.Col = table.TermStr(e.Exposure())
+		if e.EI != 0 && e.Push == 0 && !e.Stock.ISO.Contains(e.EI) {
+			ei = style(ei, conf.Theme.Warn)
+		}
+		t.AddCol(table.ColFixed(table.ColAlignRight(ei)))
 
-func (db *DB) PrintStock(w io.Writer, conf TableConfig) {
-	t := table.New()
-	space := table.TermStr(" ")
-	line := table.TermStr(conf.Separator)
-	lline := table.TermStr(strings.TrimLeft(conf.Separator, " "))
-	rline := table.TermStr(strings.TrimRight(conf.Separator, " "))
+		labStyle, labInStyle := conf.Theme.Lab, Style("")
+		if atLab {
+			labStyle, labInStyle = conf.Theme.AtLab, conf.Theme.AtLab
+		}
+		t.AddCol(table.ColFixed(style(table.TermStr(labID), conf.Theme.ID)))
+		t.AddCol(table.ColJoined(table.ColMinWidth(table.ColFixed(style(table.TermStr(labName), labStyle)), 8)))
+		t.AddCol(table.ColJoined(table.ColFixed(style(table.TermStr(labInDate), labInStyle))))
+		t.AddCol(table.ColJoined(table.ColFixed(table.TermStr(labOutDate))))
 
-	if !conf.Pretty {
-		space = line
+		t.AddCol(table.ColFixed(table.TermStr(scan)))
+		t.AddCol(table.ColFixed(table.TermStr(fmt.Sprintf("%d", e.Line))))
+		t.AddCol(table.TermStr(location(e.Location, e.Geo)))
+		for _, k := range conf.AttrColumns {
+			t.AddCol(table.TermStr(e.Attrs[k]))
+		}
+		t.AddCol(table.ColWrap(table.TermStr(e.Note)))
 	}
 
-	row := func(
-		available, shot, total,
-		stockID, stockName, stockFormat, stockISO, stockCompany,
-		camera string,
-	) {
-		t.NewRow()
+	if conf.GroupBy == GroupNone {
+		db.row(conf.IDFilter, conf.filter(add))
+		return t
+	}
 
-		if conf.StartEndWithSeperator {
-			t.AddCol(table.ColFixed(lline))
+	type item struct {
+		Entry
+		id     string
+		active bool
+	}
+	items := make([]item, 0, len(db.Entries))
+	db.row(conf.IDFilter, conf.filter(func(e Entry, id string, active bool) {
+		items = append(items, item{e, id, active})
+	}))
+
+	for _, g := range groupSorted(items, func(i item) string { return conf.GroupBy.entry(i.Entry) }) {
+		t.AddGroup(style(table.TermStr(g.Title), conf.Theme.Group))
+		for _, i := range g.Items {
+			add(i.Entry, i.id, i.active)
 		}
+		t.NewSummaryRow()
+		t.AddCol(table.ColFixed(table.TermStr(rolls(len(g.Items)))))
+	}
+
+	return t
+}
 
-		t.AddCol(table.ColFixed(table.ColAlignRight(table.TermStr(available))))
-		t.AddCol(table.ColFixed(line))
+func rolls(n int) string {
+	if n == 1 {
+		return "1 roll"
+	}
+	return fmt.Sprintf("%d rolls", n)
+}
 
-		t.AddCol(table.ColFixed(table.ColAlignRight(table.TermStr(shot))))
-		t.AddCol(table.ColFixed(line))
+func (db *DB) PrintTable(w io.Writer, conf TableConfig) error {
+	return conf.render(w, db.LogTable(conf))
+}
+
+// Tag is a key:value tag describing a roll.
+type Tag struct {
+	Key, Value string
+}
 
-		t.AddCol(table.ColFixed(table.ColAlignRight(table.TermStr(total))))
-		t.AddCol(table.ColFixed(line))
+func (t Tag) String() string { return t.Key + ":" + t.Value }
 
-		t.AddCol(table.ColFixed(table.TermStr(stockID)))
-		t.AddCol(table.ColFixed(space))
-		t.AddCol(table.ColFixed(table.TermStr(stockCompany)))
-		t.AddCol(table.ColFixed(space))
-		t.AddCol(table.ColFixed(table.TermStr(stockName)))
-		t.AddCol(table.ColFixed(space))
-		t.AddCol(table.ColFixed(table.TermStr(stockFormat)))
-		t.AddCol(table.ColFixed(space))
-		t.AddCol(table.ColFixed(table.TermStr(stockISO)))
-		t.AddCol(table.ColFixed(line))
-		t.AddCol(table.ColFixed(table.TermStr(camera)))
+// Tags returns the tags of the entry with the given id, as printed by
+// PrintTags.
+func (e Entry) Tags(id string) []Tag {
+	r := strings.NewReplacer(" ", "_")
+	clean := func(str string) string {
+		return strings.ToLower(r.Replace(str))
+	}
 
-		if conf.StartEndWithSeperator {
-			t.AddCol(table.ColFixed(rline))
+	list := make([]Tag, 0, 8)
+	// Attributes can't override tags, including the trailing line.
+	keys := map[string]struct{}{"line": {}}
+	add := func(k, v string) {
+		list = append(list, Tag{k, v})
+		keys[k] = struct{}{}
+	}
+	add("id", id)
+	add("camera", fmt.Sprintf("%s-%s", clean(e.Camera.Brand), clean(e.Camera.Model)))
+	add("film", fmt.Sprintf("%s-%s", clean(e.Stock.Company.Name), clean(e.Stock.Name)))
+	add("iso", clean(e.Stock.ISO.String()))
+	if e.EI != 0 {
+		add("ei", strconv.FormatUint(uint64(e.EI), 10))
+	}
+	if e.Push != 0 {
+		add("push", fmt.Sprintf("%+d", e.Push))
+	}
+	if e.Stock.Type != TypeUnknown {
+		add("type", string(e.Stock.Type))
+	}
+	if e.Stock.Process != ProcessUnknown {
+		add("process", string(e.Stock.Process))
+	}
+	for _, l := range e.Lenses {
+		add("lens", fmt.Sprintf("%s-%s", clean(l.Brand), clean(l.Model)))
+	}
+	if !e.Lab.None() {
+		add("lab", clean(e.Lab.Name))
+	}
+	if e.Location != "" {
+		add("location", clean(e.Location))
+	}
+	if e.Scan != 0 {
+		add("scan", fmt.Sprintf("%04d", e.Scan))
+	}
+	for _, k := range e.Attrs.Keys() {
+		if _, ok := keys[clean(k)]; ok {
+			continue
 		}
+		add(clean(k), clean(e.Attrs[k]))
 	}
+	add("line", strconv.FormatUint(uint64(e.Line), 10))
+
+	return list
+}
+
+func (db *DB) PrintTags(w io.Writer, idFilter string, filter Attrs, near *Near) {
+	list := make([]string, 0, 8)
+	db.row(idFilter, func(e Entry, id string, active bool) {
+		if !e.Attrs.Match(filter) || !near.Match(e) {
+			return
+		}
+		list = list[:0]
+		for _, t := range e.Tags(id) {
+			list = append(list, t.String())
+		}
+
+		fmt.Fprintln(w, strings.Join(list, " "))
+	})
+}
+
+func (db *DB) StockTable(conf TableConfig) *table.Table {
+	db = db.At(conf.At)
+	t := table.New()
 
 	if conf.Header {
-		row("Avail", "Shot", "Total", "SID", "Stock", "Format", "ISO", "Manufacturer", "Camera")
-	}
-	if conf.HeaderSep {
-		hs := ":---"
-		hsr := "---:"
-		row(hsr, hsr, hsr, hs, hs, hs, hs, hs, hs)
+		for _, h := range []string{
+			"Avail", "Loaded", "Shot", "Total", "Expires",
+			"SID", "Manufacturer", "Stock", "Format", "ISO",
+			"Camera",
+		} {
+			t.AddHeadCol(table.TermStr(h))
+		}
 	}
 
 	type s struct {
-		*Stock
-		*Camera
-		Rolls int
+		*inventory
+		Cameras []*Camera
 	}
 
 	sorted := make([]*s, 0, len(db.Stocks))
 	{
 		l := make(map[ID]*s, len(db.Stocks))
-		for id, stock := range db.Stocks {
-			l[id] = &s{stock, nil, stock.Rolls}
+		for id, inv := range db.inventory(conf.At) {
+			l[id] = &s{inv, nil}
 		}
 
 		db.row("", func(e Entry, id string, active bool) {
-			l[e.Stock.ID].Rolls--
 			if active {
-				l[e.Stock.ID].Camera = e.Camera
+				l[e.Stock.ID].Cameras = append(l[e.Stock.ID].Cameras, e.Camera)
 			}
 		})
 
 		for _, stock := range l {
+			slices.SortFunc(stock.Cameras, func(a, b *Camera) int { return cmp.Compare(a.ID, b.ID) })
 			sorted = append(sorted, stock)
 		}
 
 		slices.SortFunc(sorted, func(i, j *s) int {
-			return cmp.Compare(i.Name, j.Name)
+			return cmp.Compare(i.Stock.Name, j.Stock.Name)
 		})
 	}
 
-	for _, stock := range sorted {
-		var cam string
-		if stock.Camera != nil {
-			cam = fmt.Sprintf("%s %s %s", stock.Camera.ID.String(), stock.Camera.Brand, stock.Camera.Model)
+	now := conf.At
+	if now.IsZero() {
+		now = time.Now()
+	}
+	style := conf.style
+	cameras := func(stock *s) string {
+		l := make([]string, len(stock.Cameras))
+		for i, c := range stock.Cameras {
+			l[i] = c.String()
+		}
+		return strings.Join(l, ", ")
+	}
+	avail := func(n int) table.Col {
+		var c table.Col = table.TermStr(strconv.Itoa(n))
+		if n < 0 {
+			c = style(c, conf.Theme.Warn)
 		}
-		row(
-			strconv.Itoa(stock.Rolls),
-			strconv.Itoa(stock.Stock.Rolls-stock.Rolls),
-			strconv.Itoa(stock.Stock.Rolls),
-			stock.Stock.ID.String(),
-			stock.Stock.Name,
-			stock.Stock.Format,
-			stock.Stock.ISO.String(),
-			stock.Stock.Company.Name,
-			cam,
-		)
+		return c
 	}
+	add := func(stock *s) {
+		cam := style(table.TermStr(cameras(stock)), conf.Theme.Active)
 
-	if conf.Width != 0 {
-		t.SetFixedWidth(conf.Width)
+		t.NewRow()
+		t.AddCol(table.ColFixed(table.ColAlignRight(avail(stock.Available()))))
+		t.AddCol(table.ColFixed(table.ColAlignRight(table.TermStr(strconv.Itoa(len(stock.Cameras))))))
+		t.AddCol(table.ColFixed(table.ColAlignRight(table.TermStr(strconv.Itoa(stock.Shot)))))
+		t.AddCol(table.ColFixed(table.ColAlignRight(table.TermStr(strconv.Itoa(stock.Total)))))
+
+		var exp table.Col = table.TermStr("")
+		if e, n := stock.Expiry(); !e.IsZero() {
+			exp = table.TermStr(fmt.Sprintf("%s (%d)", e.Format(dateFormat), n))
+			if !e.After(now) {
+				exp = style(exp, conf.Theme.Warn)
+			}
+		}
+		t.AddCol(table.ColFixed(exp))
+
+		t.AddCol(table.ColFixed(style(table.TermStr(stock.Stock.ID.String()), conf.Theme.ID)))
+		t.AddCol(table.ColJoined(table.ColFixed(style(table.TermStr(stock.Stock.Company.Name), conf.Theme.Stock))))
+		t.AddCol(table.ColJoined(table.ColFixed(style(table.TermStr(stock.Stock.Name), conf.Theme.Stock))))
+		t.AddCol(table.ColJoined(table.ColFixed(table.TermStr(stock.Stock.Format))))
+		t.AddCol(table.ColJoined(table.ColFixed(table.TermStr(stock.Stock.ISO.String()))))
+		t.AddCol(table.ColFixed(cam))
+	}
+
+	if conf.GroupBy == GroupNone {
+		for _, stock := range sorted {
+			add(stock)
+		}
+		return t
+	}
+
+	key := func(stock *s) string {
+		switch conf.GroupBy {
+		case GroupCamera:
+			if len(stock.Cameras) == 0 {
+				return "Not loaded"
+			}
+			return cameras(stock)
+		case GroupCompany:
+			return stock.Stock.Company.String()
+		}
+		return stock.Stock.String()
+	}
+
+	if conf.GroupBy == GroupCamera {
+		// A stock loaded in several cameras is listed under each of them.
+		split := make([]*s, 0, len(sorted))
+		for _, stock := range sorted {
+			if len(stock.Cameras) < 2 {
+				split = append(split, stock)
+				continue
+			}
+			for _, c := range stock.Cameras {
+				split = append(split, &s{stock.inventory, []*Camera{c}})
+			}
+		}
+		sorted = split
 	}
-	t.WriteTo(w, "")
+
+	for _, g := range groupSorted(sorted, key) {
+		t.AddGroup(style(table.TermStr(g.Title), conf.Theme.Group))
+		var loaded, shot, total int
+		for _, stock := range g.Items {
+			add(stock)
+			loaded += len(stock.Cameras)
+			shot += stock.Shot
+			total += stock.Total
+		}
+		t.NewSummaryRow()
+		t.AddCol(table.ColFixed(table.ColAlignRight(avail(total - shot))))
+		t.AddCol(table.ColFixed(table.ColAlignRight(table.TermStr(strconv.Itoa(loaded)))))
+		t.AddCol(table.ColFixed(table.ColAlignRight(table.TermStr(strconv.Itoa(shot)))))
+		t.AddCol(table.ColFixed(table.ColAlignRight(table.TermStr(strconv.Itoa(total)))))
+	}
+
+	return t
+}
+
+func (db *DB) PrintStock(w io.Writer, conf TableConfig) error {
+	return conf.render(w, db.StockTable(conf))
 }
 
 func (db *DB) String() string {
@@ -504,3 +637,38 @@ func (db *DB) String() string {
 	db.PrintTable(buf, defaultConf)
 	return buf.String()
 }
+
+// At returns the DB as it was at the end of the given day, ignoring
+// purchases, loads and lab transitions after it. A zero t returns db.
+func (db *DB) At(t time.Time) *DB {
+	if t.IsZero() {
+		return db
+	}
+
+	n := *db
+	n.Entries = make([]Entry, 0, len(db.Entries))
+	for _, e := range db.Entries {
+		if e.LoadDate.After(t) {
+			continue
+		}
+		if e.LabOutDate.After(t) {
+			e.LabOutDate = time.Time{}
+			e.Scan = 0
+		}
+		if e.LabInDate.After(t) {
+			e.Lab = nil
+			e.LabInDate = time.Time{}
+			e.Costs = LabCosts{}
+		}
+		n.Entries = append(n.Entries, e)
+	}
+
+	n.Purchases = make([]Purchase, 0, len(db.Purchases))
+	for _, p := range db.Purchases {
+		if !p.Date.After(t) {
+			n.Purchases = append(n.Purchases, p)
+		}
+	}
+
+	return &n
+}
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/frizinak/film-rolls/exif"
+)
+
+// Tags returns the metadata as exif tags, including an XMP packet.
+func (m Metadata) Tags() (exif.Tags, error) {
+	t := exif.Tags{
+		Make:             m.Make,
+		Model:            m.Model,
+		Description:      m.Description,
+		Lens:             m.Lens,
+		ISO:              uint16(min(m.ISO, math.MaxUint16)),
+		DateTimeOriginal: m.Date,
+	}
+	if m.Geo != nil {
+		t.GPS = &exif.GPS{Lat: m.Geo.Lat, Lon: m.Geo.Lon}
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := m.WriteXMP(buf); err != nil {
+		return t, err
+	}
+	t.XMP = buf.Bytes()
+
+	return t, nil
+}
+
+// EmbedResult is the outcome of embedding metadata in a single image.
+type EmbedResult struct {
+	Image string
+	// Skipped is set for unsupported file types.
+	Skipped bool
+}
+
+// EmbedMetadata writes the metadata of the roll into each jpeg and tiff
+// image in dir, keeping a backup of the original.
+func (db *DB) EmbedMetadata(id, dir string, dryRun bool) ([]EmbedResult, error) {
+	e, err := db.Roll(id)
+	if err != nil {
+		return nil, err
+	}
+	images, err := Images(dir)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := e.Metadata(id).Tags()
+	if err != nil {
+		return nil, err
+	}
+
+	l := make([]EmbedResult, 0, len(images))
+	for _, img := range images {
+		r := EmbedResult{img, !exif.Supported(img)}
+		l = append(l, r)
+		if r.Skipped || dryRun {
+			continue
+		}
+		if err := exif.WriteFile(img, tags); err != nil {
+			return l, err
+		}
+	}
+
+	return l, nil
+}
+
+func PrintEmbed(w io.Writer, l []EmbedResult) error {
+	for _, r := range l {
+		status := "write"
+		if r.Skipped {
+			status = "skip"
+		}
+		if _, err := fmt.Fprintf(w, "%-5s %s\n", status, r.Image); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	exposureEI   = "ei"
+	exposurePush = "push"
+)
+
+// parseExposure extracts the @[ei] or ei=[ei] and push=[stops] tokens from an
+// entry line.
+func parseExposure(p []string) ([]string, uint32, int, error) {
+	var ei uint32
+	var push int
+	var pushed bool
+	rest := make([]string, 0, len(p))
+	for _, tok := range p {
+		v, ok := strings.CutPrefix(tok, "@")
+		if !ok {
+			v, ok = strings.CutPrefix(tok, exposureEI+"=")
+		}
+		if ok {
+			n, err := strconv.ParseUint(v, 10, 32)
+			if err != nil || n == 0 {
+				return p, ei, push, fmt.Errorf("invalid exposure index '%s'", tok)
+			}
+			if ei != 0 {
+				return p, ei, push, errors.New("duplicate exposure index")
+			}
+			ei = uint32(n)
+			continue
+		}
+		if v, ok := strings.CutPrefix(tok, exposurePush+"="); ok {
+			n, err := strconv.Atoi(strings.TrimPrefix(v, "+"))
+			if err != nil {
+				return p, ei, push, fmt.Errorf("invalid push '%s'", tok)
+			}
+			if pushed {
+				return p, ei, push, errors.New("duplicate push")
+			}
+			push, pushed = n, true
+			continue
+		}
+		rest = append(rest, tok)
+	}
+
+	return rest, ei, push, nil
+}
+
+// Contains reports whether ei is within the ISO range.
+func (iso ISO) Contains(ei uint32) bool {
+	return ei >= iso.Low && ei <= iso.High
+}
+
+// Exposure returns the exposure index and push/pull, e.g.: 800 (+1).
+func (e Entry) Exposure() string {
+	var s string
+	if e.EI != 0 {
+		s = strconv.FormatUint(uint64(e.EI), 10)
+	}
+	if e.Push != 0 {
+		s = strings.TrimSpace(fmt.Sprintf("%s (%+d)", s, e.Push))
+	}
+	return s
+}
@@ -0,0 +1,215 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/frizinak/film-rolls/table"
+)
+
+// Frame is a single exposure on a roll.
+type Frame struct {
+	N int
+	// Time of the exposure, midnight if only a date was given.
+	Time time.Time
+	// Timed is set when Time includes a time of day.
+	Timed    bool
+	Lens     *Lens
+	Aperture string
+	Shutter  string
+	Location string
+	Geo      *Point
+	Note     string
+
+	Line uint
+}
+
+const (
+	keywordFrame = "Frame"
+	timeFormat   = "15:04"
+)
+
+var frameRE = regexp.MustCompile(`^` + keywordFrame + `\s+[0-9]+(\s|$)`)
+
+// frameNote starts the free form note at the end of a frame line.
+const frameNote = "#"
+
+// shutterRE is the canonical shutter speed: a fraction of a second, seconds,
+// bulb or time, e.g.: 1/125, 2s, 0.5s, B or T.
+var shutterRE = regexp.MustCompile(`^(1/[1-9][0-9]*|[0-9]+(\.[0-9]+)?s|B|T)$`)
+
+// normalizeShutter converts common ways of writing a shutter speed, e.g.:
+// 1/125s or 2, to the canonical form.
+func normalizeShutter(str string) (string, error) {
+	s := strings.Join(strings.Fields(str), "")
+	if strings.HasPrefix(s, "1/") {
+		s = strings.TrimSuffix(s, "s")
+	} else if _, err := strconv.ParseFloat(s, 64); err == nil {
+		s += "s"
+	}
+	s = strings.ToUpper(s[:min(len(s), 1)]) + s[min(len(s), 1):]
+	if !shutterRE.MatchString(s) {
+		return "", fmt.Errorf("invalid shutter speed '%s'", str)
+	}
+	return s, nil
+}
+
+// mkFrame parses:
+// Frame [n] [date] [time] [lens=id] [f/aperture] [shutter] [loc=location]
+// [geo=lat,lon] [# notes]
+// where all but the frame number are optional, underscores in the location
+// are replaced with spaces.
+func (db *DB) mkFrame(p []string) (Frame, error) {
+	var f Frame
+	if len(p) < 2 || p[0] != keywordFrame {
+		return f, errors.New("invalid frame")
+	}
+	n, err := strconv.Atoi(p[1])
+	if err != nil || n < 0 {
+		return f, fmt.Errorf("invalid frame number '%s'", p[1])
+	}
+	f.N = n
+
+	p = p[2:]
+	for i, tok := range p {
+		if strings.HasPrefix(tok, frameNote) {
+			f.Note = strings.TrimSpace(strings.TrimPrefix(strings.Join(p[i:], " "), frameNote))
+			p = p[:i]
+			break
+		}
+	}
+
+	if len(p) != 0 {
+		if d, err := time.Parse(dateFormat, p[0]); err == nil {
+			f.Time = d
+			p = p[1:]
+			if len(p) != 0 {
+				if t, err := time.Parse(timeFormat, p[0]); err == nil {
+					f.Time = f.Time.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute)
+					f.Timed = true
+					p = p[1:]
+				}
+			}
+		}
+	}
+
+	for _, tok := range p {
+		if v, ok := strings.CutPrefix(tok, entryLens+"="); ok && f.Lens == nil {
+			lid, err := MkID(v)
+			if err != nil {
+				return f, err
+			}
+			if f.Lens, ok = db.Lenses[lid]; !ok {
+				return f, fmt.Errorf("no lens with id %s", lid)
+			}
+			continue
+		}
+		if v, ok := strings.CutPrefix(tok, "f/"); ok && v != "" && f.Aperture == "" {
+			f.Aperture = v
+			continue
+		}
+		if shutterRE.MatchString(tok) && f.Shutter == "" {
+			f.Shutter = tok
+			continue
+		}
+		if v, ok := strings.CutPrefix(tok, tokenLocation+"="); ok && v != "" && f.Location == "" {
+			f.Location = parseLocation(v)
+			continue
+		}
+		if v, ok := strings.CutPrefix(tok, tokenGeo+"="); ok && f.Geo == nil {
+			p, err := ParsePoint(v)
+			if err != nil {
+				return f, err
+			}
+			f.Geo = &p
+			continue
+		}
+		return f, fmt.Errorf("invalid or duplicate frame field '%s', notes start with %s", tok, frameNote)
+	}
+
+	return f, nil
+}
+
+// Format returns the frame as a log line.
+func (f Frame) Format() string {
+	l := []string{keywordFrame, strconv.Itoa(f.N)}
+	if d := f.Date(); d != "" {
+		l = append(l, d)
+	}
+	if f.Lens != nil {
+		l = append(l, entryLens+"="+string(f.Lens.ID))
+	}
+	if f.Aperture != "" {
+		l = append(l, "f/"+f.Aperture)
+	}
+	if f.Shutter != "" {
+		l = append(l, f.Shutter)
+	}
+	if f.Location != "" {
+		l = append(l, tokenLocation+"="+formatLocation(f.Location))
+	}
+	if f.Geo != nil {
+		l = append(l, tokenGeo+"="+f.Geo.String())
+	}
+	if f.Note != "" {
+		l = append(l, frameNote, f.Note)
+	}
+	return "    " + strings.Join(l, " ")
+}
+
+// Date formats the frame time, omitting the time of day when unknown.
+func (f Frame) Date() string {
+	if f.Time.IsZero() {
+		return ""
+	}
+	if !f.Timed {
+		return f.Time.Format(dateFormat)
+	}
+	return f.Time.Format(dateFormat + " " + timeFormat)
+}
+
+func (db *DB) FramesTable(conf TableConfig) *table.Table {
+	t := table.New()
+	style := conf.style
+	if conf.Header {
+		for _, h := range []string{
+			"ID", "Frame", "Date", "Lens", "Aperture", "Shutter", "Location", "Line", "Note",
+		} {
+			t.AddHeadCol(table.TermStr(h))
+		}
+	}
+
+	db.row(conf.IDFilter, conf.filter(func(e Entry, id string, active bool) {
+		for _, f := range e.Frames {
+			lens, aperture := "", ""
+			if f.Lens != nil {
+				lens = f.Lens.String()
+			}
+			if f.Aperture != "" {
+				aperture = "f/" + f.Aperture
+			}
+
+			t.NewRow()
+			t.AddCol(table.ColFixed(table.TermStr(id)))
+			t.AddCol(table.ColFixed(table.ColAlignRight(table.TermStr(strconv.Itoa(f.N)))))
+			t.AddCol(table.ColFixed(table.TermStr(f.Date())))
+			t.AddCol(table.ColMinWidth(style(table.TermStr(lens), conf.Theme.Camera), 8))
+			t.AddCol(table.ColFixed(table.ColAlignRight(table.TermStr(aperture))))
+			t.AddCol(table.ColFixed(table.ColAlignRight(table.TermStr(f.Shutter))))
+			t.AddCol(table.TermStr(location(f.Location, f.Geo)))
+			t.AddCol(table.ColFixed(table.TermStr(strconv.FormatUint(uint64(f.Line), 10))))
+			t.AddCol(table.ColWrap(table.TermStr(f.Note)))
+		}
+	}))
+
+	return t
+}
+
+func (db *DB) PrintFrames(w io.Writer, conf TableConfig) error {
+	return conf.render(w, db.FramesTable(conf))
+}
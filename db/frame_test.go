@@ -0,0 +1,146 @@
+package db
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testLog = `Company FUJ
+    Fujifilm
+
+Stock XTR
+    135
+    Superia X-Tra
+    FUJ
+    400
+    2
+
+Camera OM1 mount=om
+    Olympus
+    OM-1
+
+Lens Z50 mount=om
+    Olympus
+    G.Zuiko Auto-S
+    50mm
+    f/1.4
+`
+
+func testDB(t *testing.T, log string) *DB {
+	db, err := Parse(strings.NewReader(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	db := testDB(t, testLog)
+	day := time.Date(2023, 12, 12, 0, 0, 0, 0, time.UTC)
+	tests := []Frame{
+		{N: 1},
+		{N: 2, Time: day},
+		{N: 3, Time: day.Add(14*time.Hour + 30*time.Minute), Timed: true},
+		{N: 4, Time: day, Timed: true},
+		{N: 5, Lens: db.Lenses["Z50"], Aperture: "8", Shutter: "1/125"},
+		{N: 6, Shutter: "2s", Note: "tripod"},
+		{N: 7, Shutter: "0.5s"},
+		{N: 8, Shutter: "B", Note: "B is for bulb"},
+		{N: 9, Shutter: "T", Note: "T"},
+		{N: 10, Note: "2s into the sunset"},
+		{N: 11, Note: "1/125 # f/8 lens=Z50"},
+		{N: 12, Location: "Graslei Ghent", Geo: &Point{51.0547, 3.7206}},
+		{N: 13, Geo: &Point{-33.8568, 151.2153}, Note: "Sydney"},
+	}
+
+	for _, f := range tests {
+		line := f.Format()
+		got, err := db.mkFrame(strings.Fields(line))
+		if err != nil {
+			t.Errorf("%q: %s", line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, f) {
+			t.Errorf("%q: got %+v, want %+v", line, got, f)
+		}
+		if again := got.Format(); again != line {
+			t.Errorf("%q formatted as %q", line, again)
+		}
+	}
+}
+
+func TestMkFrame(t *testing.T) {
+	db := testDB(t, testLog)
+	tests := []struct {
+		line string
+		want Frame
+		err  bool
+	}{
+		{"Frame 1 f/8 1/125 # Boats", Frame{N: 1, Aperture: "8", Shutter: "1/125", Note: "Boats"}, false},
+		{"Frame 1 #Boats  on the river", Frame{N: 1, Note: "Boats on the river"}, false},
+		{"Frame 1 #", Frame{N: 1}, false},
+		{"Frame 1 loc=Big_Square", Frame{N: 1, Location: "Big Square"}, false},
+		{"Frame 1 1/125s", Frame{}, true},
+		{"Frame 1 Boats", Frame{}, true},
+		{"Frame 1 B T", Frame{}, true},
+		{"Frame 1 f/8 f/11", Frame{}, true},
+		{"Frame 1 lens=NOPE", Frame{}, true},
+		{"Frame 1 geo=100,0", Frame{}, true},
+		{"Frame x", Frame{}, true},
+	}
+
+	for _, test := range tests {
+		f, err := db.mkFrame(strings.Fields(test.line))
+		if test.err {
+			if err == nil {
+				t.Errorf("%q: expected error, got %+v", test.line, f)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %s", test.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(f, test.want) {
+			t.Errorf("%q: got %+v, want %+v", test.line, f, test.want)
+		}
+	}
+}
+
+func TestNormalizeShutter(t *testing.T) {
+	tests := []struct {
+		in, want string
+		err      bool
+	}{
+		{"1/125", "1/125", false},
+		{"1/125s", "1/125", false},
+		{"1/125 s", "1/125", false},
+		{"2", "2s", false},
+		{"0.5", "0.5s", false},
+		{"4s", "4s", false},
+		{"b", "B", false},
+		{"T", "T", false},
+		{"bulb", "", true},
+		{"1/0", "", true},
+		{"fast", "", true},
+	}
+
+	for _, test := range tests {
+		s, err := normalizeShutter(test.in)
+		if test.err {
+			if err == nil {
+				t.Errorf("%q: expected error, got %q", test.in, s)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %s", test.in, err)
+			continue
+		}
+		if s != test.want {
+			t.Errorf("%q: got %q, want %q", test.in, s, test.want)
+		}
+	}
+}
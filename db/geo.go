@@ -0,0 +1,247 @@
+package db
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Point is a WGS84 coordinate.
+type Point struct {
+	Lat, Lon float64
+}
+
+func (p Point) String() string {
+	return strconv.FormatFloat(p.Lat, 'f', -1, 64) + "," + strconv.FormatFloat(p.Lon, 'f', -1, 64)
+}
+
+// ParsePoint parses lat,lon.
+func ParsePoint(str string) (Point, error) {
+	var p Point
+	lat, lon, ok := strings.Cut(str, ",")
+	if !ok {
+		return p, fmt.Errorf("invalid coordinates '%s', expected lat,lon", str)
+	}
+	var err1, err2 error
+	p.Lat, err1 = strconv.ParseFloat(strings.TrimSpace(lat), 64)
+	p.Lon, err2 = strconv.ParseFloat(strings.TrimSpace(lon), 64)
+	if err1 != nil || err2 != nil || math.Abs(p.Lat) > 90 || math.Abs(p.Lon) > 180 {
+		return p, fmt.Errorf("invalid coordinates '%s'", str)
+	}
+	return p, nil
+}
+
+const earthRadius = 6371.0
+
+// Distance returns the great-circle distance in km.
+func (p Point) Distance(o Point) float64 {
+	rad := func(d float64) float64 { return d * math.Pi / 180 }
+	dLat := rad(o.Lat - p.Lat)
+	dLon := rad(o.Lon - p.Lon)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(rad(p.Lat))*math.Cos(rad(o.Lat))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+// Near is an area around a point.
+type Near struct {
+	Point
+	Km float64
+}
+
+// ParseNear parses lat,lon,km.
+func ParseNear(str string) (*Near, error) {
+	i := strings.LastIndex(str, ",")
+	if i == -1 {
+		return nil, fmt.Errorf("invalid area '%s', expected lat,lon,km", str)
+	}
+	p, err := ParsePoint(str[:i])
+	if err != nil {
+		return nil, err
+	}
+	km, err := strconv.ParseFloat(str[i+1:], 64)
+	if err != nil || km < 0 {
+		return nil, fmt.Errorf("invalid distance '%s'", str[i+1:])
+	}
+	return &Near{p, km}, nil
+}
+
+// Match reports whether the entry or any of its frames is within the area.
+func (n *Near) Match(e Entry) bool {
+	if n == nil {
+		return true
+	}
+	if e.Geo != nil && e.Geo.Distance(n.Point) <= n.Km {
+		return true
+	}
+	for _, f := range e.Frames {
+		if f.Geo != nil && f.Geo.Distance(n.Point) <= n.Km {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	tokenGeo      = "geo"
+	tokenLocation = "loc"
+)
+
+func parseLocation(v string) string { return strings.ReplaceAll(v, "_", " ") }
+
+func formatLocation(v string) string { return strings.ReplaceAll(v, " ", "_") }
+
+// location returns the location name or the coordinates if unnamed.
+func location(name string, geo *Point) string {
+	if name != "" || geo == nil {
+		return name
+	}
+	return geo.String()
+}
+
+// parseGeo extracts the geo=[lat,lon] and loc=[location] tokens from an entry
+// line.
+func parseGeo(p []string) ([]string, *Point, string, error) {
+	var geo *Point
+	var loc string
+	rest := make([]string, 0, len(p))
+	for _, tok := range p {
+		if v, ok := strings.CutPrefix(tok, tokenGeo+"="); ok {
+			pt, err := ParsePoint(v)
+			if err != nil {
+				return p, geo, loc, err
+			}
+			geo = &pt
+			continue
+		}
+		if v, ok := strings.CutPrefix(tok, tokenLocation+"="); ok {
+			loc = parseLocation(v)
+			continue
+		}
+		rest = append(rest, tok)
+	}
+	return rest, geo, loc, nil
+}
+
+type place struct {
+	Point
+	Name string
+	Desc string
+	Time time.Time
+	Kind string
+
+	Roll  string
+	Frame int
+}
+
+// places returns the rolls and frames with coordinates.
+func (db *DB) places(conf TableConfig) []place {
+	l := make([]place, 0)
+	db.row(conf.IDFilter, conf.filter(func(e Entry, id string, active bool) {
+		desc := e.Stock.String() + " / " + e.Camera.String()
+		if e.Geo != nil {
+			name := e.Location
+			if name == "" {
+				name = id
+			}
+			l = append(l, place{*e.Geo, name, desc, e.LoadDate, "roll", id, 0})
+		}
+		for _, f := range e.Frames {
+			if f.Geo == nil {
+				continue
+			}
+			name := f.Location
+			if name == "" {
+				name = fmt.Sprintf("%s #%d", id, f.N)
+			}
+			d := desc
+			if f.Note != "" {
+				d += " / " + f.Note
+			}
+			l = append(l, place{*f.Geo, name, d, f.Time, "frame", id, f.N})
+		}
+	}))
+	return l
+}
+
+func (db *DB) PrintGeoJSON(w io.Writer, conf TableConfig) error {
+	type geometry struct {
+		Type        string    `json:"type"`
+		Coordinates []float64 `json:"coordinates"`
+	}
+	type feature struct {
+		Type       string         `json:"type"`
+		Geometry   geometry       `json:"geometry"`
+		Properties map[string]any `json:"properties"`
+	}
+	col := struct {
+		Type     string    `json:"type"`
+		Features []feature `json:"features"`
+	}{Type: "FeatureCollection", Features: make([]feature, 0)}
+
+	for _, p := range db.places(conf) {
+		props := map[string]any{
+			"kind":        p.Kind,
+			"name":        p.Name,
+			"description": p.Desc,
+			"roll":        p.Roll,
+		}
+		if p.Kind == "frame" {
+			props["frame"] = p.Frame
+		}
+		if !p.Time.IsZero() {
+			props["time"] = p.Time.Format(time.RFC3339)
+		}
+		col.Features = append(col.Features, feature{
+			Type:       "Feature",
+			Geometry:   geometry{"Point", []float64{p.Lon, p.Lat}},
+			Properties: props,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(col)
+}
+
+func (db *DB) PrintGPX(w io.Writer, conf TableConfig) error {
+	type wpt struct {
+		Lat  float64 `xml:"lat,attr"`
+		Lon  float64 `xml:"lon,attr"`
+		Time string  `xml:"time,omitempty"`
+		Name string  `xml:"name"`
+		Desc string  `xml:"desc,omitempty"`
+		Type string  `xml:"type"`
+	}
+	gpx := struct {
+		XMLName xml.Name `xml:"gpx"`
+		Version string   `xml:"version,attr"`
+		Creator string   `xml:"creator,attr"`
+		XMLNS   string   `xml:"xmlns,attr"`
+		Wpts    []wpt    `xml:"wpt"`
+	}{Version: "1.1", Creator: "film-rolls", XMLNS: "http://www.topografix.com/GPX/1/1"}
+
+	for _, p := range db.places(conf) {
+		var t string
+		if !p.Time.IsZero() {
+			t = p.Time.Format(time.RFC3339)
+		}
+		gpx.Wpts = append(gpx.Wpts, wpt{p.Lat, p.Lon, t, p.Name, p.Desc, p.Kind})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "    ")
+	if err := enc.Encode(gpx); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
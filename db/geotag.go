@@ -0,0 +1,220 @@
+package db
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"time"
+)
+
+// TrackPoint is a timestamped coordinate of a GPX track.
+type TrackPoint struct {
+	Point
+	Time time.Time
+}
+
+// ReadGPX reads all timestamped track points of a GPX file, in
+// chronological order and converted to the wall clock of loc as the log has
+// no notion of time zones.
+func ReadGPX(r io.Reader, loc *time.Location) ([]TrackPoint, error) {
+	type pt struct {
+		Lat  float64 `xml:"lat,attr"`
+		Lon  float64 `xml:"lon,attr"`
+		Time string  `xml:"time"`
+	}
+	var gpx struct {
+		Trk []struct {
+			Seg []struct {
+				Pt []pt `xml:"trkpt"`
+			} `xml:"trkseg"`
+		} `xml:"trk"`
+		Wpt []pt `xml:"wpt"`
+	}
+	if err := xml.NewDecoder(r).Decode(&gpx); err != nil {
+		return nil, fmt.Errorf("invalid gpx: %w", err)
+	}
+
+	l := make([]TrackPoint, 0)
+	add := func(p pt) error {
+		if p.Time == "" {
+			return nil
+		}
+		t, err := time.Parse(time.RFC3339, p.Time)
+		if err != nil {
+			return fmt.Errorf("invalid gpx time '%s'", p.Time)
+		}
+		t = t.In(loc)
+		wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+		l = append(l, TrackPoint{Point{p.Lat, p.Lon}, wall})
+		return nil
+	}
+	for _, trk := range gpx.Trk {
+		for _, seg := range trk.Seg {
+			for _, p := range seg.Pt {
+				if err := add(p); err != nil {
+					return l, err
+				}
+			}
+		}
+	}
+	for _, p := range gpx.Wpt {
+		if err := add(p); err != nil {
+			return l, err
+		}
+	}
+
+	slices.SortStableFunc(l, func(a, b TrackPoint) int { return a.Time.Compare(b.Time) })
+	return l, nil
+}
+
+// nearest returns the track point closest in time to t, if within maxDiff.
+func nearest(track []TrackPoint, t time.Time, maxDiff time.Duration) (TrackPoint, bool) {
+	i, _ := slices.BinarySearchFunc(track, t, func(p TrackPoint, t time.Time) int {
+		return p.Time.Compare(t)
+	})
+	var best TrackPoint
+	diff := time.Duration(math.MaxInt64)
+	for _, n := range []int{i - 1, i} {
+		if n < 0 || n >= len(track) {
+			continue
+		}
+		d := track[n].Time.Sub(t).Abs()
+		if d < diff {
+			best, diff = track[n], d
+		}
+	}
+	return best, diff <= maxDiff
+}
+
+func round6(p Point) Point {
+	r := func(f float64) float64 { return math.Round(f*1e6) / 1e6 }
+	return Point{r(p.Lat), r(p.Lon)}
+}
+
+// Geotag is a roll or frame that was or couldn't be geotagged.
+type Geotag struct {
+	Entry *Entry
+	// Frame is nil for the roll itself.
+	Frame *Frame
+	Geo   *Point
+	// Reason is set when no location was found.
+	Reason string
+}
+
+// Geotag assigns the coordinates of the track to the frames without
+// coordinates whose time is within maxDiff of a track point, and to the rolls
+// without coordinates that were loaded while the track was recorded, using
+// their first geotagged frame or the first track point while loaded.
+// Only rolls and frames within the time span of the track are returned.
+func (db *DB) Geotag(track []TrackPoint, maxDiff time.Duration) []Geotag {
+	l := make([]Geotag, 0)
+	if len(track) == 0 {
+		return l
+	}
+	first := track[0].Time.Truncate(24 * time.Hour)
+	last := track[len(track)-1].Time
+
+	unload := db.unloadDates()
+	for i := range db.Entries {
+		e := &db.Entries[i]
+		end := unload[i]
+		if unknownEnd(e, end) || (!end.IsZero() && !end.After(e.LoadDate)) {
+			end = e.LoadDate.AddDate(0, 0, 1)
+		}
+		if e.LoadDate.After(last) || (!end.IsZero() && !end.After(first)) {
+			continue
+		}
+
+		var roll *Point
+		for n := range e.Frames {
+			f := &e.Frames[n]
+			if f.Geo != nil {
+				continue
+			}
+			if !f.Timed {
+				if !f.Time.IsZero() && !f.Time.Before(first) && !f.Time.After(last) {
+					l = append(l, Geotag{e, f, nil, "frame has no time of day"})
+				}
+				continue
+			}
+			if f.Time.Before(first) || f.Time.After(last) {
+				continue
+			}
+			p, ok := nearest(track, f.Time, maxDiff)
+			if !ok {
+				l = append(l, Geotag{e, f, nil, fmt.Sprintf("no track point within %s", maxDiff)})
+				continue
+			}
+			geo := round6(p.Point)
+			if roll == nil {
+				roll = &geo
+			}
+			l = append(l, Geotag{e, f, &geo, ""})
+		}
+
+		if e.Geo != nil {
+			continue
+		}
+		if roll == nil {
+			for _, p := range track {
+				if !p.Time.Before(e.LoadDate) && (end.IsZero() || p.Time.Before(end)) {
+					geo := round6(p.Point)
+					roll = &geo
+					break
+				}
+			}
+		}
+		if roll == nil {
+			l = append(l, Geotag{e, nil, nil, "no track point while loaded"})
+			continue
+		}
+		l = append(l, Geotag{e, nil, roll, ""})
+	}
+
+	return l
+}
+
+// GeotagRewrite returns the modifications adding the found coordinates to the
+// log.
+func GeotagRewrite(l []Geotag) *Rewrite {
+	r := NewRewrite()
+	for _, g := range l {
+		if g.Geo == nil {
+			continue
+		}
+		line := g.Entry.Line
+		if g.Frame != nil {
+			line = g.Frame.Line
+		}
+		r.Append(line, tokenGeo+"="+g.Geo.String())
+	}
+	return r
+}
+
+// PrintGeotag reports which rolls and frames were or couldn't be geotagged.
+func (db *DB) PrintGeotag(w io.Writer, l []Geotag) error {
+	var tagged, failed int
+	for _, g := range l {
+		what := fmt.Sprintf("line %d: roll %s %s", g.Entry.Line, g.Entry.Stock.ID, g.Entry.Camera.ID)
+		if g.Frame != nil {
+			what = fmt.Sprintf("line %d: frame %d", g.Frame.Line, g.Frame.N)
+		}
+
+		var err error
+		if g.Geo == nil {
+			failed++
+			_, err = fmt.Fprintf(w, "%s: not matched: %s\n", what, g.Reason)
+		} else {
+			tagged++
+			_, err = fmt.Fprintf(w, "%s -> %s\n", what, g.Geo)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%d geotagged, %d not matched\n", tagged, failed)
+	return err
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type GroupBy string
+
+const (
+	GroupNone    GroupBy = ""
+	GroupCamera  GroupBy = "camera"
+	GroupStock   GroupBy = "stock"
+	GroupCompany GroupBy = "company"
+	GroupLab     GroupBy = "lab"
+	GroupYear    GroupBy = "year"
+	GroupMonth   GroupBy = "month"
+)
+
+var (
+	LogGroups   = []GroupBy{GroupCamera, GroupStock, GroupCompany, GroupLab, GroupYear, GroupMonth}
+	StockGroups = []GroupBy{GroupCamera, GroupStock, GroupCompany}
+)
+
+// ParseGroupBy returns the GroupBy named str if it is one of valid.
+func ParseGroupBy(str string, valid []GroupBy) (GroupBy, error) {
+	if str == "" {
+		return GroupNone, nil
+	}
+	g := GroupBy(str)
+	if !slices.Contains(valid, g) {
+		l := make([]string, len(valid))
+		for i := range valid {
+			l[i] = string(valid[i])
+		}
+		return g, fmt.Errorf("invalid group '%s', expected one of: %s", str, strings.Join(l, ", "))
+	}
+	return g, nil
+}
+
+func (g GroupBy) entry(e Entry) string {
+	switch g {
+	case GroupCamera:
+		return e.Camera.String()
+	case GroupStock:
+		return e.Stock.String()
+	case GroupCompany:
+		return e.Stock.Company.String()
+	case GroupLab:
+		return e.Lab.String()
+	case GroupYear:
+		return e.LoadDate.Format("2006")
+	case GroupMonth:
+		return e.LoadDate.Format("2006-01")
+	}
+	return ""
+}
+
+type group[T any] struct {
+	Title string
+	Items []T
+}
+
+// groupSorted buckets items by key, sorting the groups by their title.
+func groupSorted[T any](items []T, key func(T) string) []group[T] {
+	m := make(map[string]int)
+	groups := make([]group[T], 0)
+	for _, item := range items {
+		k := key(item)
+		i, ok := m[k]
+		if !ok {
+			i = len(groups)
+			m[k] = i
+			groups = append(groups, group[T]{Title: k})
+		}
+		groups[i].Items = append(groups[i].Items, item)
+	}
+
+	slices.SortStableFunc(groups, func(a, b group[T]) int {
+		return strings.Compare(a.Title, b.Title)
+	})
+
+	return groups
+}
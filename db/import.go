@@ -0,0 +1,315 @@
+package db
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ImportedFrame is a frame read from a logging app export and the entry it
+// was matched to, if any.
+type ImportedFrame struct {
+	// Record is the 1-based index of the frame in the export.
+	Record int
+	Camera string
+	Frame  Frame
+	Entry  *Entry
+	// Reason is set when the frame wasn't matched.
+	Reason string
+}
+
+// importKeys are the accepted field names of an export, the first being
+// the canonical one.
+var importKeys = map[string][]string{
+	"frame":    {"frame", "frame_number", "number"},
+	"time":     {"time", "date", "datetime", "timestamp", "taken_at"},
+	"camera":   {"camera"},
+	"lens":     {"lens"},
+	"aperture": {"aperture", "fstop", "f_number"},
+	"shutter":  {"shutter", "shutter_speed"},
+	"location": {"location", "place"},
+	"lat":      {"lat", "latitude"},
+	"lon":      {"lon", "lng", "longitude"},
+	"note":     {"note", "notes", "description"},
+}
+
+var importTimeFormats = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	dateFormat,
+}
+
+// ReadExport reads the records of a json or csv export, based on the
+// extension of name. Json exports are a list of objects, optionally in a
+// "frames" field, csv exports have a header row.
+func ReadExport(r io.Reader, name string) ([]map[string]string, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return readJSONExport(r)
+	case ".csv":
+		return readCSVExport(r)
+	}
+	return nil, fmt.Errorf("unsupported export '%s', expected a .json or .csv file", name)
+}
+
+func readJSONExport(r io.Reader) ([]map[string]string, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	var l []map[string]any
+	if err := json.Unmarshal(raw, &l); err != nil {
+		var wrap struct {
+			Frames []map[string]any `json:"frames"`
+		}
+		if err := json.Unmarshal(raw, &wrap); err != nil {
+			return nil, errors.New("json export should be a list of frames")
+		}
+		l = wrap.Frames
+	}
+
+	records := make([]map[string]string, len(l))
+	for i, obj := range l {
+		records[i] = make(map[string]string, len(obj))
+		for k, v := range obj {
+			if v == nil {
+				continue
+			}
+			records[i][importKey(k)] = fmt.Sprint(v)
+		}
+	}
+	return records, nil
+}
+
+func readCSVExport(r io.Reader) ([]map[string]string, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	head := rows[0]
+	records := make([]map[string]string, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		rec := make(map[string]string, len(head))
+		for i, k := range head {
+			if i < len(row) {
+				rec[importKey(k)] = strings.TrimSpace(row[i])
+			}
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+var importKeyReplacer = strings.NewReplacer(" ", "_", "-", "_")
+
+func importKey(k string) string {
+	return importKeyReplacer.Replace(strings.ToLower(strings.TrimSpace(k)))
+}
+
+func importField(rec map[string]string, key string) string {
+	for _, k := range importKeys[key] {
+		if v := rec[k]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// importTime parses a timestamp keeping its wall clock, as the log has no
+// notion of time zones, and reports whether it has a time of day.
+func importTime(str string) (time.Time, bool, error) {
+	for _, f := range importTimeFormats {
+		if t, err := time.Parse(f, str); err == nil {
+			return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC), f != dateFormat, nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("invalid time '%s'", str)
+}
+
+func (db *DB) lookupCamera(str string) *Camera {
+	for _, c := range db.Cameras {
+		if strings.EqualFold(string(c.ID), str) || strings.EqualFold(c.Short(), str) {
+			return c
+		}
+	}
+	return nil
+}
+
+func (db *DB) lookupLens(str string) *Lens {
+	for _, l := range db.Lenses {
+		if strings.EqualFold(string(l.ID), str) || strings.EqualFold(l.Brand+" "+l.Model, str) {
+			return l
+		}
+	}
+	return nil
+}
+
+// ImportFrames matches the records of an export to the roll that was in the
+// given camera at the time of the frame. Frames without a camera are matched
+// if only one roll was loaded at that time, frames whose number already
+// exists on the roll are skipped. Removed rolls without a known removal date
+// are never matched.
+func (db *DB) ImportFrames(records []map[string]string) []ImportedFrame {
+	unload := db.unloadDates()
+	seen := make(map[*Entry]map[int]struct{})
+	for i := range db.Entries {
+		e := &db.Entries[i]
+		seen[e] = make(map[int]struct{}, len(e.Frames))
+		for _, f := range e.Frames {
+			seen[e][f.N] = struct{}{}
+		}
+	}
+
+	l := make([]ImportedFrame, 0, len(records))
+	for n, rec := range records {
+		imp := ImportedFrame{Record: n + 1, Camera: importField(rec, "camera")}
+		l = append(l, imp)
+		last := &l[len(l)-1]
+
+		var err error
+		last.Frame.N, err = strconv.Atoi(importField(rec, "frame"))
+		if err != nil {
+			last.Reason = "no frame number"
+			continue
+		}
+		ts := importField(rec, "time")
+		if ts == "" {
+			last.Reason = "no time"
+			continue
+		}
+		if last.Frame.Time, last.Frame.Timed, err = importTime(ts); err != nil {
+			last.Reason = err.Error()
+			continue
+		}
+		// Values are normalized so the frame parses back from the log.
+		last.Frame.Aperture = strings.Join(strings.Fields(strings.TrimPrefix(importField(rec, "aperture"), "f/")), "")
+		if v := importField(rec, "shutter"); v != "" {
+			if last.Frame.Shutter, err = normalizeShutter(v); err != nil {
+				last.Reason = err.Error()
+				continue
+			}
+		}
+		last.Frame.Location = parseLocation(strings.Join(strings.Fields(importField(rec, "location")), " "))
+		last.Frame.Note = strings.Join(strings.Fields(importField(rec, "note")), " ")
+		if lat, lon := importField(rec, "lat"), importField(rec, "lon"); lat != "" && lon != "" {
+			p, err := ParsePoint(lat + "," + lon)
+			if err != nil {
+				last.Reason = err.Error()
+				continue
+			}
+			last.Frame.Geo = &p
+		}
+
+		var cam *Camera
+		if last.Camera != "" {
+			if cam = db.lookupCamera(last.Camera); cam == nil {
+				last.Reason = fmt.Sprintf("unknown camera '%s'", last.Camera)
+				continue
+			}
+		}
+		if lens := importField(rec, "lens"); lens != "" {
+			if last.Frame.Lens = db.lookupLens(lens); last.Frame.Lens == nil {
+				last.Reason = fmt.Sprintf("unknown lens '%s'", lens)
+				continue
+			}
+		}
+
+		// The roll loaded last wins when rolls were swapped that day.
+		day := last.Frame.Time.Truncate(24 * time.Hour)
+		cams := make(map[*Camera]*Entry)
+		for i := range db.Entries {
+			e := &db.Entries[i]
+			if cam != nil && e.Camera != cam {
+				continue
+			}
+			if unknownEnd(e, unload[i]) || day.Before(e.LoadDate) || (!unload[i].IsZero() && day.After(unload[i])) {
+				continue
+			}
+			if m, ok := cams[e.Camera]; !ok || e.LoadDate.After(m.LoadDate) {
+				cams[e.Camera] = e
+			}
+		}
+		match := make([]*Entry, 0, len(cams))
+		for _, e := range cams {
+			match = append(match, e)
+		}
+
+		switch {
+		case len(match) == 0:
+			last.Reason = "no roll loaded at that time"
+		case len(match) > 1:
+			last.Reason = fmt.Sprintf("%d rolls loaded at that time", len(match))
+		default:
+			if _, ok := seen[match[0]][last.Frame.N]; ok {
+				last.Reason = "frame already logged"
+				continue
+			}
+			seen[match[0]][last.Frame.N] = struct{}{}
+			last.Entry = match[0]
+		}
+	}
+
+	return l
+}
+
+// ImportRewrite returns the modifications adding the matched frames to the
+// log.
+func ImportRewrite(l []ImportedFrame) *Rewrite {
+	r := NewRewrite()
+	for _, f := range l {
+		if f.Entry != nil {
+			r.Insert(f.Entry.End, f.Frame.Format())
+		}
+	}
+	return r
+}
+
+// PrintImport reports which frames were matched to which rolls.
+func (db *DB) PrintImport(w io.Writer, l []ImportedFrame) error {
+	ids := make(map[*Entry]string, len(db.Entries))
+	var i int
+	db.row("", func(e Entry, id string, active bool) {
+		ids[&db.Entries[i]] = id
+		i++
+	})
+
+	var matched int
+	for _, f := range l {
+		var err error
+		if f.Entry == nil {
+			_, err = fmt.Fprintf(w, "record %d: frame %d: skipped: %s\n", f.Record, f.Frame.N, f.Reason)
+		} else {
+			matched++
+			_, err = fmt.Fprintf(
+				w,
+				"record %d: frame %d -> %s line %d %s %s\n",
+				f.Record,
+				f.Frame.N,
+				ids[f.Entry],
+				f.Entry.Line,
+				f.Entry.Stock.ID,
+				f.Entry.Camera.ID,
+			)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%d of %d frames matched\n", matched, len(l))
+	return err
+}
@@ -0,0 +1,79 @@
+package db
+
+import "testing"
+
+const testImportLog = testLog + `
+Camera OM2
+    Olympus
+    OM-2n
+
+Camera ZNT
+    Зенит
+    12СД
+
+Lab FSL
+    De Foto Studio
+
+2023-01-05 XTR ZNT -
+
+2023-01-10 XTR OM1 FSL 2023-02-01
+
+2023-01-20 XTR OM1 FSL 2023-01-25
+
+2023-03-01 XTR OM1
+    Frame 1
+
+2023-03-05 XTR OM2
+`
+
+func TestImportFrames(t *testing.T) {
+	db := testDB(t, testImportLog)
+	tests := []struct {
+		name   string
+		camera string
+		time   string
+		frame  string
+		// want is the load date and camera of the matched roll or the reason
+		// the frame was skipped.
+		want string
+	}{
+		{"still loaded", "OM1", "2023-03-10T12:00", "2", "2023-03-01 OM1"},
+		{"still loaded by name", "olympus om-2n", "2023-04-01", "1", "2023-03-05 OM2"},
+		{"lab-in day", "OM1", "2023-01-25 18:00", "5", "2023-01-20 OM1"},
+		{"after lab-in", "OM1", "2023-01-26 10:00", "6", "no roll loaded at that time"},
+		{"before next load", "OM1", "2023-01-15", "1", "2023-01-10 OM1"},
+		{"overlapping", "OM1", "2023-01-20 09:00", "1", "2023-01-20 OM1"},
+		{"before every roll", "OM1", "2022-12-01T10:00:00Z", "1", "no roll loaded at that time"},
+		{"removed without end", "ZNT", "2023-01-06", "1", "no roll loaded at that time"},
+		{"no camera", "", "2023-01-15", "2", "2023-01-10 OM1"},
+		{"no camera ambiguous", "", "2023-03-10", "3", "2 rolls loaded at that time"},
+		{"already logged", "OM1", "2023-03-10", "1", "frame already logged"},
+		{"imported twice", "OM1", "2023-03-11", "2", "frame already logged"},
+		{"unknown camera", "NOPE", "2023-03-10", "1", "unknown camera 'NOPE'"},
+		{"no time", "OM1", "", "1", "no time"},
+		{"no frame number", "OM1", "2023-03-10", "", "no frame number"},
+	}
+
+	records := make([]map[string]string, len(tests))
+	for i, test := range tests {
+		records[i] = map[string]string{
+			"camera": test.camera,
+			"time":   test.time,
+			"frame":  test.frame,
+		}
+	}
+
+	l := db.ImportFrames(records)
+	if len(l) != len(tests) {
+		t.Fatalf("got %d frames, want %d", len(l), len(tests))
+	}
+	for i, test := range tests {
+		got := l[i].Reason
+		if e := l[i].Entry; e != nil {
+			got = e.LoadDate.Format(dateFormat) + " " + string(e.Camera.ID)
+		}
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Lens struct {
+	ID       ID
+	Brand    string
+	Model    string
+	Focal    string
+	Aperture string
+	Mount    string
+}
+
+func (l *Lens) String() string {
+	return fmt.Sprintf("%s %s", l.ID, l.Short())
+}
+
+func (l *Lens) Short() string {
+	s := make([]string, 0, 4)
+	for _, v := range []string{l.Brand, l.Model} {
+		if v != "" {
+			s = append(s, v)
+		}
+	}
+	if l.Focal != "" {
+		s = append(s, l.Focal+"mm")
+	}
+	if l.Aperture != "" {
+		s = append(s, "f/"+l.Aperture)
+	}
+	return strings.Join(s, " ")
+}
+
+const (
+	optionMount = "mount"
+	entryLens   = "lens"
+)
+
+func (c *Camera) option(k, v string) error {
+	switch k {
+	case optionMount:
+		c.Mount = v
+	default:
+		return fmt.Errorf("invalid camera option '%s'", k)
+	}
+	return nil
+}
+
+func (l *Lens) option(k, v string) error {
+	switch k {
+	case optionMount:
+		l.Mount = v
+	default:
+		return fmt.Errorf("invalid lens option '%s'", k)
+	}
+	return nil
+}
+
+// Fits reports whether the lens mount matches the camera mount, an unknown
+// mount always fits.
+func (c *Camera) Fits(l *Lens) bool {
+	return c.Mount == "" || l.Mount == "" || strings.EqualFold(c.Mount, l.Mount)
+}
+
+// parseLenses extracts the lens=[lens-id,...] token from an entry line.
+func (db *DB) parseLenses(p []string) ([]string, []*Lens, error) {
+	var l []*Lens
+	rest := make([]string, 0, len(p))
+	for _, tok := range p {
+		v, ok := strings.CutPrefix(tok, entryLens+"=")
+		if !ok {
+			rest = append(rest, tok)
+			continue
+		}
+		for _, id := range strings.Split(v, ",") {
+			lid, err := MkID(id)
+			if err != nil {
+				return p, l, err
+			}
+			lens, ok := db.Lenses[lid]
+			if !ok {
+				return p, l, fmt.Errorf("no lens with id %s", lid)
+			}
+			l = append(l, lens)
+		}
+	}
+	return rest, l, nil
+}
@@ -0,0 +1,126 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Roll returns the entry with the given id.
+func (db *DB) Roll(id string) (*Entry, error) {
+	if id == "" {
+		return nil, errors.New("no roll id given")
+	}
+	var i, n int = 0, -1
+	db.row("", func(e Entry, rid string, active bool) {
+		if rid == id {
+			n = i
+		}
+		i++
+	})
+	if n == -1 {
+		return nil, fmt.Errorf("no roll with id '%s'", id)
+	}
+	return &db.Entries[n], nil
+}
+
+// Metadata is the roll metadata embedded in scans.
+type Metadata struct {
+	Make  string
+	Model string
+	Lens  string
+	ISO   uint32
+	Date  time.Time
+	Geo   *Point
+
+	Description string
+	// Keywords are the roll's tags as printed by PrintTags.
+	Keywords []string
+	// Hierarchy are | separated hierarchical keywords.
+	Hierarchy []string
+}
+
+// Metadata returns the metadata of the entry with the given id.
+func (e Entry) Metadata(id string) Metadata {
+	m := Metadata{
+		Make:  e.Camera.Brand,
+		Model: e.Camera.Model,
+		ISO:   e.Stock.ISO.Low,
+		Date:  e.LoadDate,
+		Geo:   e.Geo,
+	}
+	if e.EI != 0 {
+		m.ISO = e.EI
+	}
+	if len(e.Lenses) != 0 {
+		m.Lens = e.Lenses[0].Brand + " " + e.Lenses[0].Model
+	}
+
+	for _, t := range e.Tags(id) {
+		m.Keywords = append(m.Keywords, t.String())
+	}
+
+	h := func(l ...string) { m.Hierarchy = append(m.Hierarchy, strings.Join(l, "|")) }
+	h("film", e.Stock.Company.Name, e.Stock.Name)
+	h("camera", e.Camera.Brand, e.Camera.Model)
+	h("iso", fmt.Sprint(m.ISO))
+	h("roll", id)
+	for _, l := range e.Lenses {
+		h("lens", l.Brand+" "+l.Model)
+	}
+	if !e.Lab.None() {
+		h("lab", e.Lab.Name)
+	}
+	if e.Location != "" {
+		h("location", e.Location)
+	}
+
+	desc := []string{fmt.Sprintf(
+		"%s %s %s ISO %s",
+		e.Stock.Company.Name,
+		e.Stock.Name,
+		e.Stock.Format,
+		e.Stock.ISO,
+	)}
+	if ei := e.Exposure(); ei != "" {
+		desc = append(desc, "EI "+ei)
+	}
+	desc = append(desc, fmt.Sprintf("shot with %s %s", e.Camera.Brand, e.Camera.Model))
+	desc = append(desc, "loaded "+e.LoadDate.Format(dateFormat))
+	if !e.Lab.None() {
+		lab := "developed by " + e.Lab.Name
+		if !e.LabInDate.IsZero() {
+			lab += " " + e.LabInDate.Format(dateFormat)
+		}
+		if !e.LabOutDate.IsZero() {
+			lab += " - " + e.LabOutDate.Format(dateFormat)
+		}
+		desc = append(desc, lab)
+	}
+	desc = append(desc, "roll "+id)
+	m.Description = strings.Join(desc, ", ")
+
+	return m
+}
+
+var imageExts = []string{".jpg", ".jpeg", ".tif", ".tiff", ".png", ".dng", ".webp"}
+
+// Images returns the sorted paths of the images in dir.
+func Images(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	l := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !slices.Contains(imageExts, strings.ToLower(filepath.Ext(e.Name()))) {
+			continue
+		}
+		l = append(l, filepath.Join(dir, e.Name()))
+	}
+	return l, nil
+}
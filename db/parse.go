@@ -14,11 +14,13 @@ const dateFormat = "2006-01-02"
 
 func Parse(r io.Reader) (*DB, error) {
 	db := &DB{
-		Entries: make([]Entry, 0),
+		Entries:   make([]Entry, 0),
+		Purchases: make([]Purchase, 0),
 
 		Companies: make(map[ID]*Company, 0),
 		Stocks:    make(map[ID]*Stock, 0),
 		Cameras:   make(map[ID]*Camera, 0),
+		Lenses:    make(map[ID]*Lens, 0),
 		Labs:      make(map[ID]*Lab, 0),
 	}
 
@@ -30,8 +32,10 @@ func Parse(r io.Reader) (*DB, error) {
 		keywordCompany = "Company"
 		keywordStock   = "Stock"
 		keywordCamera  = "Camera"
+		keywordLens    = "Lens"
 		keywordLab     = "Lab"
 		keywordEntry   = "Entry"
+		keywordBuy     = "Buy"
 	)
 
 	s := bufio.NewScanner(r)
@@ -134,6 +138,23 @@ func Parse(r io.Reader) (*DB, error) {
 				keyword = keywordNone
 			}
 			continue
+		case keywordLens:
+			l, ok := db.Lenses[lastID]
+			if !ok {
+				return db, fmt.Errorf("no lens with id %s", lastID)
+			}
+
+			if l.Brand == "" {
+				l.Brand = t
+			} else if l.Model == "" {
+				l.Model = t
+			} else if l.Focal == "" {
+				l.Focal = strings.TrimSuffix(t, "mm")
+			} else if l.Aperture == "" {
+				l.Aperture = strings.TrimPrefix(t, "f/")
+				keyword = keywordNone
+			}
+			continue
 		case keywordLab:
 			l, ok := db.Labs[lastID]
 			if !ok {
@@ -143,9 +164,38 @@ func Parse(r io.Reader) (*DB, error) {
 			keyword = keywordNone
 			continue
 		case keywordEntry:
-			db.Entries[len(db.Entries)-1].Note = t
+			e := &db.Entries[len(db.Entries)-1]
+			if frameRE.MatchString(t) {
+				f, err := db.mkFrame(strings.Fields(t))
+				// The first line could be a note predating frames.
+				if err != nil && e.Note == "" && len(e.Frames) == 0 {
+					e.Note = t
+					e.End = line
+					continue
+				}
+				if err != nil {
+					return db, fmt.Errorf("%w: line %d: '%s'", err, line, t)
+				}
+				f.Line = line
+				e.Frames = append(e.Frames, f)
+				e.End = line
+				continue
+			}
+			if e.Note == "" {
+				e.Note = t
+				e.End = line
+				continue
+			}
+			keyword = keywordNone
+		case keywordBuy:
+			// The shop is on an indented line, an unindented line starts
+			// the next definition, e.g.: another purchase without a shop.
+			if raw := s.Text(); raw[0] == ' ' || raw[0] == '\t' {
+				db.Purchases[len(db.Purchases)-1].Shop = t
+				keyword = keywordNone
+				continue
+			}
 			keyword = keywordNone
-			continue
 		}
 
 		p := strings.Fields(t)
@@ -157,13 +207,25 @@ func Parse(r io.Reader) (*DB, error) {
 				return db, fmt.Errorf("%w: line %d: '%s'", err, line, t)
 			}
 
-			e.Line = line
+			e.Line, e.End = line, line
 			db.Entries = append(db.Entries, e)
 			keyword = keywordEntry
 			continue
 		}
 
-		if len(p) != 2 {
+		if p[0] == keywordBuy {
+			b, err := db.mkPurchase(p)
+			if err != nil {
+				return db, fmt.Errorf("%w: line %d: '%s'", err, line, t)
+			}
+
+			b.Line = line
+			db.Purchases = append(db.Purchases, b)
+			keyword = keywordBuy
+			continue
+		}
+
+		if len(p) < 2 {
 			return db, fmt.Errorf("invalid line %d: '%s'", line, t)
 		}
 
@@ -174,6 +236,7 @@ func Parse(r io.Reader) (*DB, error) {
 		}
 
 		lastID = id
+		var opt func(k, v string) error
 		switch keyword {
 		case keywordCompany:
 			if _, ok := db.Companies[id]; ok {
@@ -185,32 +248,82 @@ func Parse(r io.Reader) (*DB, error) {
 				return db, fmt.Errorf("duplicate stock id '%s'", id.String())
 			}
 			db.Stocks[id] = &Stock{ID: id}
+			opt = db.Stocks[id].option
 		case keywordCamera:
 			if _, ok := db.Cameras[id]; ok {
 				return db, fmt.Errorf("duplicate camera id '%s'", id.String())
 			}
 			db.Cameras[id] = &Camera{ID: id}
+			opt = db.Cameras[id].option
+		case keywordLens:
+			if _, ok := db.Lenses[id]; ok {
+				return db, fmt.Errorf("duplicate lens id '%s'", id.String())
+			}
+			db.Lenses[id] = &Lens{ID: id}
+			opt = db.Lenses[id].option
 		case keywordLab:
 			if _, ok := db.Labs[id]; ok {
 				return db, fmt.Errorf("duplicate lab id '%s'", id.String())
 			}
 			db.Labs[id] = &Lab{ID: id}
+			opt = db.Labs[id].option
 		default:
 			return db, fmt.Errorf("invalid keyword: '%s'", keyword)
 		}
+
+		if len(p) > 2 {
+			if opt == nil {
+				return db, fmt.Errorf("invalid line %d: '%s'", line, t)
+			}
+			if err := parseOptions(p[2:], opt); err != nil {
+				return db, fmt.Errorf("%w: line %d: '%s'", err, line, t)
+			}
+		}
 	}
 
 	if err := s.Err(); err != nil {
 		return db, err
 	}
+
+	for _, s := range db.Stocks {
+		if s.Process == ProcessUnknown {
+			s.Process = s.Type.Process()
+		}
+	}
+
 	return db, nil
 }
 
 func (db *DB) mkEntry(d time.Time, p []string, scans map[uint]struct{}) (Entry, error) {
 	e := Entry{LoadDate: d}
+	p, attrs := trailing(p)
+	attrs, costs, err := parseCosts(attrs)
+	if err != nil {
+		return e, err
+	}
+	e.Costs = costs
+	attrs, e.EI, e.Push, err = parseExposure(attrs)
+	if err != nil {
+		return e, err
+	}
+	attrs, e.Lenses, err = db.parseLenses(attrs)
+	if err != nil {
+		return e, err
+	}
+	attrs, e.Geo, e.Location, err = parseGeo(attrs)
+	if err != nil {
+		return e, err
+	}
+	e.Attrs, err = parseAttrs(attrs)
+	if err != nil {
+		return e, err
+	}
 	if len(p) < 3 {
 		return e, errors.New("invalid entry")
 	}
+	if !costs.Zero() && (len(p) < 4 || p[3] == "-" || p[3] == "--" || p[3] == "---") {
+		return e, errors.New("entry without lab can't have lab costs")
+	}
 	sid, err := MkID(p[1])
 	if err != nil {
 		return e, err
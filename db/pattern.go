@@ -0,0 +1,67 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Pattern is a file name pattern with {key} placeholders for the tags of a
+// roll, {key:0N} zero pads the value to N characters.
+type Pattern string
+
+// DefaultScanPattern names scan folders by their binder page.
+const DefaultScanPattern Pattern = "{scan}"
+
+// Vars returns the values available to a pattern: the tags of the entry, the
+// load date and stock as an alias of film.
+func (e Entry) Vars(id string) map[string]string {
+	tags := e.Tags(id)
+	m := make(map[string]string, len(tags)+2)
+	for _, t := range tags {
+		if _, ok := m[t.Key]; !ok {
+			m[t.Key] = t.Value
+		}
+	}
+	m["date"] = e.LoadDate.Format(dateFormat)
+	m["stock"] = m["film"]
+	return m
+}
+
+var patternReplacer = strings.NewReplacer("/", "-", "\\", "-")
+
+// Expand replaces the placeholders of p with the given values.
+func (p Pattern) Expand(vars map[string]string) (string, error) {
+	var b strings.Builder
+	str := string(p)
+	for {
+		i := strings.IndexByte(str, '{')
+		if i == -1 {
+			b.WriteString(str)
+			break
+		}
+		j := strings.IndexByte(str[i:], '}')
+		if j == -1 {
+			return "", fmt.Errorf("unterminated placeholder in pattern '%s'", p)
+		}
+		b.WriteString(str[:i])
+		key, width, _ := strings.Cut(str[i+1:i+j], ":")
+		str = str[i+j+1:]
+
+		v, ok := vars[key]
+		if !ok {
+			return "", fmt.Errorf("no %s for pattern '%s'", key, p)
+		}
+		if width != "" {
+			n, err := strconv.Atoi(width)
+			if err != nil || n < 0 || width[0] != '0' {
+				return "", fmt.Errorf("invalid width '%s' in pattern '%s'", width, p)
+			}
+			if pad := n - len(v); pad > 0 {
+				v = strings.Repeat("0", pad) + v
+			}
+		}
+		b.WriteString(patternReplacer.Replace(v))
+	}
+	return b.String(), nil
+}
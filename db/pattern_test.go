@@ -0,0 +1,48 @@
+package db
+
+import "testing"
+
+func TestPatternExpand(t *testing.T) {
+	vars := map[string]string{
+		"scan":   "12",
+		"frame":  "3",
+		"stock":  "Portra 400",
+		"camera": "Olympus/OM-1",
+		"lens":   `50\1.8`,
+	}
+	tests := []struct {
+		pattern Pattern
+		want    string
+		err     bool
+	}{
+		{"", "", false},
+		{"plain", "plain", false},
+		{"{scan}", "12", false},
+		{"{scan:04}-{frame:02}", "0012-03", false},
+		{"{frame:01}", "3", false},
+		{"{scan:00}", "12", false},
+		{"roll {stock} ({camera})", "roll Portra 400 (Olympus-OM-1)", false},
+		{"{lens}", "50-1.8", false},
+		{"{missing}", "", true},
+		{"{scan:4}", "", true},
+		{"{scan:0x}", "", true},
+		{"{scan", "", true},
+	}
+
+	for _, test := range tests {
+		s, err := test.pattern.Expand(vars)
+		if test.err {
+			if err == nil {
+				t.Errorf("%q: expected error, got %q", test.pattern, s)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %s", test.pattern, err)
+			continue
+		}
+		if s != test.want {
+			t.Errorf("%q: got %q, want %q", test.pattern, s, test.want)
+		}
+	}
+}
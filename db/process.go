@@ -0,0 +1,155 @@
+package db
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type FilmType string
+
+const (
+	TypeUnknown       FilmType = ""
+	TypeColorNegative FilmType = "color-negative"
+	TypeBW            FilmType = "bw"
+	TypeSlide         FilmType = "slide"
+	TypeMotionPicture FilmType = "motion-picture"
+)
+
+var FilmTypes = []FilmType{TypeColorNegative, TypeBW, TypeSlide, TypeMotionPicture}
+
+func (t FilmType) String() string {
+	switch t {
+	case TypeColorNegative:
+		return "Color negative"
+	case TypeBW:
+		return "B&W"
+	case TypeSlide:
+		return "Slide"
+	case TypeMotionPicture:
+		return "Motion picture"
+	}
+	return "Unknown"
+}
+
+// Process returns the usual development process of the film type.
+func (t FilmType) Process() Process {
+	switch t {
+	case TypeColorNegative:
+		return ProcessC41
+	case TypeBW:
+		return ProcessBW
+	case TypeSlide:
+		return ProcessE6
+	case TypeMotionPicture:
+		return ProcessECN2
+	}
+	return ProcessUnknown
+}
+
+type Process string
+
+const (
+	ProcessUnknown Process = ""
+	ProcessC41     Process = "c-41"
+	ProcessE6      Process = "e-6"
+	ProcessBW      Process = "bw"
+	ProcessECN2    Process = "ecn-2"
+)
+
+var Processes = []Process{ProcessC41, ProcessE6, ProcessBW, ProcessECN2}
+
+func (p Process) String() string {
+	switch p {
+	case ProcessUnknown:
+		return "Unknown"
+	case ProcessBW:
+		return "B&W"
+	}
+	return strings.ToUpper(string(p))
+}
+
+func parseFilmType(str string) (FilmType, error) {
+	t := FilmType(strings.ToLower(str))
+	if !slices.Contains(FilmTypes, t) {
+		return t, fmt.Errorf("invalid film type '%s'", str)
+	}
+	return t, nil
+}
+
+func parseProcess(str string) (Process, error) {
+	p := Process(strings.ToLower(str))
+	if !slices.Contains(Processes, p) {
+		return p, fmt.Errorf("invalid process '%s'", str)
+	}
+	return p, nil
+}
+
+// parseProcesses parses a comma separated list of processes.
+func parseProcesses(str string) ([]Process, error) {
+	l := strings.Split(str, ",")
+	p := make([]Process, len(l))
+	for i := range l {
+		var err error
+		if p[i], err = parseProcess(l[i]); err != nil {
+			return p, err
+		}
+	}
+	return p, nil
+}
+
+const (
+	optionType    = "type"
+	optionProcess = "process"
+	optionReorder = "reorder"
+)
+
+// parseOptions parses the key=value tokens of a definition line.
+func parseOptions(p []string, opt func(k, v string) error) error {
+	for _, tok := range p {
+		k, v, ok := strings.Cut(tok, "=")
+		if !ok || v == "" {
+			return fmt.Errorf("invalid option '%s'", tok)
+		}
+		if err := opt(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Stock) option(k, v string) error {
+	var err error
+	switch k {
+	case optionType:
+		s.Type, err = parseFilmType(v)
+	case optionProcess:
+		s.Process, err = parseProcess(v)
+	case optionReorder:
+		s.Reorder, err = strconv.Atoi(v)
+		if err != nil || s.Reorder < 1 {
+			return fmt.Errorf("invalid reorder threshold '%s'", v)
+		}
+	default:
+		return fmt.Errorf("invalid stock option '%s'", k)
+	}
+	return err
+}
+
+func (l *Lab) option(k, v string) error {
+	var err error
+	switch k {
+	case optionProcess:
+		l.Processes, err = parseProcesses(v)
+	default:
+		return fmt.Errorf("invalid lab option '%s'", k)
+	}
+	return err
+}
+
+// Supports reports whether the lab develops the given process, labs without
+// a list of processes are assumed to support all of them.
+func (l *Lab) Supports(p Process) bool {
+	return p == ProcessUnknown || len(l.Processes) == 0 || slices.Contains(l.Processes, p)
+}
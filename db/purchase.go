@@ -0,0 +1,324 @@
+package db
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Price is an amount in cents of a currency.
+type Price struct {
+	Cents    int64
+	Currency string
+}
+
+// ParsePrice parses an amount with an optional currency pre- or suffix,
+// e.g.: 12.50, 12.50EUR, EUR12.50 or €12.5.
+func ParsePrice(str string) (Price, error) {
+	var p Price
+	num := strings.TrimFunc(str, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.' && r != ','
+	})
+	if num == "" {
+		return p, fmt.Errorf("invalid price '%s'", str)
+	}
+	i := strings.Index(str, num)
+	pre, suf := strings.TrimSpace(str[:i]), strings.TrimSpace(str[i+len(num):])
+	if pre != "" && suf != "" {
+		return p, fmt.Errorf("invalid price '%s'", str)
+	}
+	p.Currency = pre + suf
+	// A currency is made of letters and currency symbols, which rules out signs.
+	if strings.ContainsFunc(p.Currency, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.Is(unicode.Sc, r)
+	}) {
+		return p, fmt.Errorf("invalid currency in price '%s'", str)
+	}
+
+	num = strings.Replace(num, ",", ".", 1)
+	whole, frac, _ := strings.Cut(num, ".")
+	if len(frac) > 2 {
+		return p, fmt.Errorf("invalid price '%s': too many decimals", str)
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+	if whole == "" {
+		whole = "0"
+	}
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return p, fmt.Errorf("invalid price '%s'", str)
+	}
+	f, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil {
+		return p, fmt.Errorf("invalid price '%s'", str)
+	}
+	p.Cents = w*100 + f
+
+	return p, nil
+}
+
+func (p Price) String() string {
+	s := fmt.Sprintf("%d.%02d", p.Cents/100, p.Cents%100)
+	if p.Currency == "" {
+		return s
+	}
+	return s + " " + p.Currency
+}
+
+func (p Price) Zero() bool { return p == Price{} }
+
+// Purchase is a batch of rolls of a single stock.
+type Purchase struct {
+	Stock  *Stock
+	Date   time.Time
+	Rolls  int
+	Price  Price
+	Expiry time.Time
+	Shop   string
+
+	Line uint
+}
+
+const monthFormat = "2006-01"
+
+// parseExpiry parses a date or a month, the latter expiring at the end of
+// that month.
+func parseExpiry(str string) (time.Time, error) {
+	if t, err := time.Parse(dateFormat, str); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(monthFormat, str)
+	if err != nil {
+		return t, fmt.Errorf("invalid expiry date '%s'", str)
+	}
+	return t.AddDate(0, 1, -1), nil
+}
+
+// mkPurchase parses: Buy [stock-id] [date] [qty] [price] [expiry].
+// A price of - can be used to only specify the expiry.
+func (db *DB) mkPurchase(p []string) (Purchase, error) {
+	var b Purchase
+	if len(p) < 4 || len(p) > 6 {
+		return b, errors.New("invalid purchase")
+	}
+
+	sid, err := MkID(p[1])
+	if err != nil {
+		return b, err
+	}
+	var ok bool
+	b.Stock, ok = db.Stocks[sid]
+	if !ok {
+		return b, fmt.Errorf("no stock with id %s", sid)
+	}
+
+	b.Date, err = time.Parse(dateFormat, p[2])
+	if err != nil {
+		return b, fmt.Errorf("error in purchase date: %w", err)
+	}
+
+	b.Rolls, err = strconv.Atoi(p[3])
+	if err != nil || b.Rolls < 1 {
+		return b, fmt.Errorf("invalid number of rolls '%s'", p[3])
+	}
+
+	if len(p) > 4 && p[4] != "-" {
+		b.Price, err = ParsePrice(p[4])
+		if err != nil {
+			return b, err
+		}
+	}
+
+	if len(p) > 5 {
+		b.Expiry, err = parseExpiry(p[5])
+		if err != nil {
+			return b, err
+		}
+	}
+
+	return b, nil
+}
+
+// Batch is a purchase and the amount of its rolls that weren't loaded yet.
+// Purchase is nil for rolls from a Stock's #rolls line, which are considered
+// the oldest batch.
+type Batch struct {
+	Purchase  *Purchase
+	Remaining int
+}
+
+func (b Batch) Expiry() time.Time {
+	if b.Purchase == nil {
+		return time.Time{}
+	}
+	return b.Purchase.Expiry
+}
+
+type inventory struct {
+	Stock *Stock
+	// Total rolls bought.
+	Total int
+	// Shot is the amount of rolls loaded in a camera.
+	Shot int
+	// Batches in order of purchase, loaded rolls are taken from the oldest
+	// batch bought before the roll was loaded.
+	Batches []Batch
+}
+
+func (i inventory) Available() int { return i.Total - i.Shot }
+
+// Expiry returns the soonest expiry date of any batch with remaining rolls.
+func (i inventory) Expiry() (time.Time, int) {
+	var exp time.Time
+	var n int
+	for _, b := range i.Batches {
+		e := b.Expiry()
+		if b.Remaining <= 0 || e.IsZero() {
+			continue
+		}
+		if exp.IsZero() || e.Before(exp) {
+			exp, n = e, b.Remaining
+		}
+	}
+	return exp, n
+}
+
+// inventory returns the stock state per stock id as of the end of the day
+// at. A zero at ignores the date.
+func (db *DB) inventory(at time.Time) map[ID]*inventory {
+	inv := make(map[ID]*inventory, len(db.Stocks))
+	for id, s := range db.Stocks {
+		inv[id] = &inventory{Stock: s, Total: s.Rolls}
+		if s.Rolls != 0 {
+			inv[id].Batches = append(inv[id].Batches, Batch{nil, s.Rolls})
+		}
+	}
+
+	purchases := make([]*Purchase, 0, len(db.Purchases))
+	for i := range db.Purchases {
+		if at.IsZero() || !db.Purchases[i].Date.After(at) {
+			purchases = append(purchases, &db.Purchases[i])
+		}
+	}
+	slices.SortStableFunc(purchases, func(a, b *Purchase) int {
+		return a.Date.Compare(b.Date)
+	})
+	for _, b := range purchases {
+		i := inv[b.Stock.ID]
+		i.Total += b.Rolls
+		i.Batches = append(i.Batches, Batch{b, b.Rolls})
+	}
+
+	entries := make([]*Entry, 0, len(db.Entries))
+	for i := range db.Entries {
+		if at.IsZero() || !db.Entries[i].LoadDate.After(at) {
+			entries = append(entries, &db.Entries[i])
+		}
+	}
+	slices.SortStableFunc(entries, func(a, b *Entry) int {
+		return a.LoadDate.Compare(b.LoadDate)
+	})
+	for _, e := range entries {
+		i := inv[e.Stock.ID]
+		i.Shot++
+		take := -1
+		for n, b := range i.Batches {
+			if b.Remaining <= 0 {
+				continue
+			}
+			if take == -1 {
+				take = n
+			}
+			if b.Purchase == nil || !b.Purchase.Date.After(e.LoadDate) {
+				take = n
+				break
+			}
+		}
+		if take != -1 {
+			i.Batches[take].Remaining--
+		}
+	}
+
+	return inv
+}
+
+// ExpiryWarning reports rolls of a stock that are expired or about to.
+type ExpiryWarning struct {
+	Stock   *Stock
+	Rolls   int
+	Expiry  time.Time
+	Expired bool
+}
+
+func (w ExpiryWarning) String() string {
+	verb := "expires"
+	if w.Expired {
+		verb = "expired"
+	}
+	return fmt.Sprintf(
+		"%d roll(s) of %s %s on %s",
+		w.Rolls,
+		w.Stock.String(),
+		verb,
+		w.Expiry.Format(dateFormat),
+	)
+}
+
+// ExpiryWarnings returns warnings for each batch that is expired or expires
+// within the given amount of months as of now, sorted by expiry date.
+func (db *DB) ExpiryWarnings(now time.Time, months int) []ExpiryWarning {
+	soon := now.AddDate(0, months, 0)
+	l := make([]ExpiryWarning, 0)
+	for _, i := range db.inventory(now) {
+		for _, b := range i.Batches {
+			e := b.Expiry()
+			if b.Remaining <= 0 || e.IsZero() || e.After(soon) {
+				continue
+			}
+			l = append(l, ExpiryWarning{i.Stock, b.Remaining, e, e.Before(now)})
+		}
+	}
+	slices.SortFunc(l, func(a, b ExpiryWarning) int {
+		if c := a.Expiry.Compare(b.Expiry); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Stock.ID, b.Stock.ID)
+	})
+
+	return l
+}
+
+// ShortageWarning reports a stock with more rolls loaded than bought.
+type ShortageWarning struct {
+	Stock *Stock
+	Rolls int
+}
+
+func (w ShortageWarning) String() string {
+	return fmt.Sprintf(
+		"%d more roll(s) of %s loaded than bought",
+		w.Rolls,
+		w.Stock.String(),
+	)
+}
+
+// ShortageWarnings returns a warning for each stock with a negative amount of
+// available rolls as of now, sorted by stock id.
+func (db *DB) ShortageWarnings(now time.Time) []ShortageWarning {
+	l := make([]ShortageWarning, 0)
+	for _, i := range db.inventory(now) {
+		if n := i.Available(); n < 0 {
+			l = append(l, ShortageWarning{i.Stock, -n})
+		}
+	}
+	slices.SortFunc(l, func(a, b ShortageWarning) int {
+		return cmp.Compare(a.Stock.ID, b.Stock.ID)
+	})
+
+	return l
+}
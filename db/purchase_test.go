@@ -0,0 +1,204 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Price
+		err  bool
+	}{
+		{"12", Price{Cents: 1200}, false},
+		{"12.5", Price{Cents: 1250}, false},
+		{"12,99", Price{Cents: 1299}, false},
+		{".5", Price{Cents: 50}, false},
+		{"9.99EUR", Price{Cents: 999, Currency: "EUR"}, false},
+		{"€9.99", Price{Cents: 999, Currency: "€"}, false},
+		{"$ 0.05", Price{Cents: 5, Currency: "$"}, false},
+		{"12 zł", Price{Cents: 1200, Currency: "zł"}, false},
+		{"-5", Price{}, true},
+		{"+5", Price{}, true},
+		{"-5EUR", Price{}, true},
+		{"5%", Price{}, true},
+		{"EUR5USD", Price{}, true},
+		{"1.234", Price{}, true},
+		{"1.2.3", Price{}, true},
+		{"EUR", Price{}, true},
+		{"", Price{}, true},
+	}
+
+	for _, test := range tests {
+		p, err := ParsePrice(test.in)
+		if test.err {
+			if err == nil {
+				t.Errorf("ParsePrice(%q): expected error, got %+v", test.in, p)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParsePrice(%q): %s", test.in, err)
+			continue
+		}
+		if p != test.want {
+			t.Errorf("ParsePrice(%q) = %+v, want %+v", test.in, p, test.want)
+		}
+	}
+}
+
+func TestInventory(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse(dateFormat, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	tests := []struct {
+		name  string
+		log   string
+		at    time.Time
+		want  []int
+		avail int
+		short int
+	}{
+		{
+			"baseline first",
+			`
+Buy XTR 2023-03-01 2
+Buy XTR 2023-02-01 1
+
+2023-01-10 XTR OM1
+
+2023-01-20 XTR OM1
+
+2023-03-05 XTR OM1
+
+2023-03-06 XTR OM1
+`,
+			time.Time{},
+			[]int{0, 0, 1},
+			1,
+			0,
+		},
+		{
+			"bought after loading",
+			`
+Buy XTR 2023-02-01 1
+Buy XTR 2023-03-01 2
+
+2023-01-10 XTR OM1
+
+2023-01-20 XTR OM1
+
+2023-01-25 XTR OM1
+
+2023-03-05 XTR OM1
+`,
+			time.Time{},
+			[]int{0, 0, 1},
+			1,
+			0,
+		},
+		{
+			"purchases after at",
+			`
+Buy XTR 2023-02-01 1
+Buy XTR 2023-03-01 2
+
+2023-01-10 XTR OM1
+
+2023-01-20 XTR OM1
+
+2023-01-25 XTR OM1
+
+2023-03-05 XTR OM1
+`,
+			day("2023-02-20"),
+			[]int{0, 0},
+			0,
+			0,
+		},
+		{
+			"over-consumption",
+			`
+Buy XTR 2023-02-01 1
+
+2023-01-10 XTR OM1
+
+2023-01-20 XTR OM1
+
+2023-02-05 XTR OM1
+
+2023-02-06 XTR OM1
+
+2023-02-07 XTR OM1
+`,
+			time.Time{},
+			[]int{0, 0},
+			-2,
+			2,
+		},
+	}
+
+	for _, test := range tests {
+		db := testDB(t, testLog+test.log)
+		i := db.inventory(test.at)["XTR"]
+		got := make([]int, len(i.Batches))
+		for n, b := range i.Batches {
+			got[n] = b.Remaining
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: remaining %v, want %v", test.name, got, test.want)
+		}
+		if i.Available() != test.avail {
+			t.Errorf("%s: available %d, want %d", test.name, i.Available(), test.avail)
+		}
+
+		var short int
+		for _, w := range db.ShortageWarnings(test.at) {
+			short += w.Rolls
+		}
+		if short != test.short {
+			t.Errorf("%s: shortage %d, want %d", test.name, short, test.short)
+		}
+	}
+}
+
+func TestExpiryWarnings(t *testing.T) {
+	db := testDB(t, testLog+`
+Stock ABC
+    135
+    Acros
+    FUJ
+    100
+
+Buy XTR 2023-01-01 1 - 2023-11
+Buy XTR 2023-01-02 1 - 2024-03
+Buy ABC 2023-01-03 1 - 2024-03
+Buy XTR 2023-01-04 1 - 2025-01
+Buy ABC 2023-01-05 1 - 2023-12
+
+2023-02-01 XTR OM1
+
+2023-02-02 XTR OM1
+
+2023-02-03 XTR OM1
+`)
+	now := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	got := make([]string, 0)
+	for _, w := range db.ExpiryWarnings(now, 3) {
+		got = append(got, w.String())
+	}
+	want := []string{
+		"1 roll(s) of " + db.Stocks["ABC"].String() + " expired on 2023-12-31",
+		"1 roll(s) of " + db.Stocks["ABC"].String() + " expires on 2024-03-31",
+		"1 roll(s) of " + db.Stocks["XTR"].String() + " expires on 2024-03-31",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+}
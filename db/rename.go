@@ -0,0 +1,240 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/frizinak/film-rolls/exif"
+)
+
+// DefaultRenamePattern names scans by binder page, frame, stock and camera.
+const DefaultRenamePattern Pattern = "{scan}-{frame:02}-{stock}-{camera}"
+
+// Rename is a single file rename within a directory.
+type Rename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// RenamePlan is a list of renames, also used as undo manifest.
+type RenamePlan struct {
+	Dir     string   `json:"dir"`
+	Renames []Rename `json:"renames"`
+}
+
+// companions returns the names of the sidecars and backups of an image.
+func companions(image string) []string {
+	return []string{
+		XMPLightroom.Sidecar(image),
+		XMPDarktable.Sidecar(image),
+		image + exif.BackupSuffix,
+	}
+}
+
+// RenameScans plans renaming the images in dir, in name order, using the
+// pattern with the tags of the roll and {frame}, the 1-based position of
+// the image. The extension is kept and XMP sidecars and backups are renamed
+// along with their image. Images that already have their new name are left
+// out.
+func (db *DB) RenameScans(id, dir string, pattern Pattern) (RenamePlan, error) {
+	plan := RenamePlan{}
+	e, err := db.Roll(id)
+	if err != nil {
+		return plan, err
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		return plan, err
+	}
+	plan.Dir = dir
+	images, err := Images(dir)
+	if err != nil {
+		return plan, err
+	}
+
+	vars := e.Vars(id)
+	seen := make(map[string]struct{})
+	for i, img := range images {
+		from := filepath.Base(img)
+		vars["frame"] = strconv.Itoa(i + 1)
+		name, err := pattern.Expand(vars)
+		if err != nil {
+			return plan, err
+		}
+		to := name + filepath.Ext(from)
+		if to == from {
+			continue
+		}
+
+		plan.Renames = append(plan.Renames, Rename{from, to})
+		from, to = filepath.Join(dir, from), filepath.Join(dir, to)
+		for n, c := range companions(from) {
+			if _, ok := seen[c]; ok {
+				continue
+			}
+			if _, err := os.Stat(c); err == nil {
+				seen[c] = struct{}{}
+				plan.Renames = append(plan.Renames, Rename{filepath.Base(c), filepath.Base(companions(to)[n])})
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return plan, err
+			}
+		}
+	}
+
+	return plan, plan.check()
+}
+
+// check verifies no file is overwritten or renamed twice. A name may be both
+// renamed and a new name, e.g.: when swapping frames.
+func (r RenamePlan) check() error {
+	from := make(map[string]struct{}, len(r.Renames))
+	to := make(map[string]struct{}, len(r.Renames))
+	for _, n := range r.Renames {
+		if _, ok := from[n.From]; ok {
+			return fmt.Errorf("%s is renamed twice", n.From)
+		}
+		from[n.From] = struct{}{}
+	}
+	for _, n := range r.Renames {
+		if _, ok := to[n.To]; ok {
+			return fmt.Errorf("multiple files would be renamed to %s, use {frame} in the pattern", n.To)
+		}
+		to[n.To] = struct{}{}
+		if _, ok := from[n.To]; ok {
+			continue
+		}
+		if err := notExists(filepath.Join(r.Dir, n.To)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func notExists(path string) error {
+	if _, err := os.Lstat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Undo returns the plan reverting r.
+func (r RenamePlan) Undo() RenamePlan {
+	u := RenamePlan{Dir: r.Dir, Renames: make([]Rename, len(r.Renames))}
+	for i, n := range r.Renames {
+		u.Renames[len(r.Renames)-1-i] = Rename{n.To, n.From}
+	}
+	return u
+}
+
+// ManifestPath returns a new manifest path in the directory of the plan,
+// named with the given suffix.
+func (r RenamePlan) ManifestPath(suffix string) string {
+	return filepath.Join(r.Dir, ".film-rolls-rename-"+suffix+".json")
+}
+
+// ReadRenameManifest reads the plan of a previous rename.
+func ReadRenameManifest(path string) (RenamePlan, error) {
+	var r RenamePlan
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return r, err
+	}
+	if err := json.Unmarshal(b, &r); err != nil {
+		return r, fmt.Errorf("invalid rename manifest %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Apply renames the files after writing the plan to manifest, unless
+// manifest is empty. Files are first moved to temporary names so they can
+// take each other's names. On error the renames are rolled back, what can't
+// be rolled back is left in the manifest.
+func (r RenamePlan) Apply(manifest string) error {
+	if err := r.check(); err != nil {
+		return err
+	}
+	if len(r.Renames) == 0 {
+		return nil
+	}
+	if err := r.write(manifest); err != nil {
+		return err
+	}
+
+	tmp := RenamePlan{Dir: r.Dir, Renames: make([]Rename, len(r.Renames))}
+	final := RenamePlan{Dir: r.Dir, Renames: make([]Rename, len(r.Renames))}
+	for i, n := range r.Renames {
+		t := fmt.Sprintf(".film-rolls-tmp-%d-%s", i, n.From)
+		if err := notExists(filepath.Join(r.Dir, t)); err != nil {
+			return err
+		}
+		tmp.Renames[i] = Rename{n.From, t}
+		final.Renames[i] = Rename{t, n.To}
+	}
+
+	done := RenamePlan{Dir: r.Dir}
+	err := tmp.move(&done)
+	if err == nil {
+		err = final.move(&done)
+	}
+	if err == nil {
+		return nil
+	}
+
+	var undone RenamePlan
+	if rerr := done.Undo().move(&undone); rerr != nil {
+		done.Renames = done.Renames[:len(done.Renames)-len(undone.Renames)]
+		if werr := done.write(manifest); werr != nil {
+			return errors.Join(err, rerr, werr)
+		}
+		return errors.Join(err, fmt.Errorf("rolling back: %w", rerr))
+	}
+	if manifest != "" {
+		if rerr := os.Remove(manifest); rerr != nil {
+			return errors.Join(err, rerr)
+		}
+	}
+	return fmt.Errorf("%w (rolled back)", err)
+}
+
+// move renames the files in order, appending each completed rename to done.
+func (r RenamePlan) move(done *RenamePlan) error {
+	for _, n := range r.Renames {
+		if err := os.Rename(filepath.Join(r.Dir, n.From), filepath.Join(r.Dir, n.To)); err != nil {
+			return err
+		}
+		done.Renames = append(done.Renames, n)
+	}
+	return nil
+}
+
+func (r RenamePlan) write(manifest string) error {
+	if manifest == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(manifest, append(b, '\n'), 0o644)
+}
+
+func PrintRenames(w io.Writer, r RenamePlan) error {
+	width := 0
+	for _, n := range r.Renames {
+		width = max(width, utf8.RuneCountInString(n.From))
+	}
+	for _, n := range r.Renames {
+		if _, err := fmt.Fprintf(w, "%-*s -> %s\n", width, n.From, n.To); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func testDir(t *testing.T, files ...string) string {
+	dir := t.TempDir()
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(dir, f), []byte(f), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// contents maps the name of each file in dir to its content, which is its
+// original name.
+func contents(t *testing.T, dir string) map[string]string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := make(map[string]string, len(entries))
+	for _, e := range entries {
+		b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		m[e.Name()] = string(b)
+	}
+	return m
+}
+
+func TestRenamePlanCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		renames []Rename
+		err     bool
+	}{
+		{"empty", nil, nil, false},
+		{"simple", []string{"a"}, []Rename{{"a", "b"}}, false},
+		{"swap", []string{"a", "b"}, []Rename{{"a", "b"}, {"b", "a"}}, false},
+		{"shift", []string{"a", "b"}, []Rename{{"a", "b"}, {"b", "c"}}, false},
+		{"exists", []string{"a", "b"}, []Rename{{"a", "b"}}, true},
+		{"same target", []string{"a", "b"}, []Rename{{"a", "c"}, {"b", "c"}}, true},
+		{"twice", []string{"a"}, []Rename{{"a", "b"}, {"a", "c"}}, true},
+	}
+
+	for _, test := range tests {
+		r := RenamePlan{Dir: testDir(t, test.files...), Renames: test.renames}
+		err := r.check()
+		if test.err && err == nil {
+			t.Errorf("%s: expected error", test.name)
+		} else if !test.err && err != nil {
+			t.Errorf("%s: %s", test.name, err)
+		}
+	}
+}
+
+func TestRenamePlanUndo(t *testing.T) {
+	r := RenamePlan{Dir: "dir", Renames: []Rename{{"a", "b"}, {"b", "c"}, {"d", "e"}}}
+	want := RenamePlan{Dir: "dir", Renames: []Rename{{"e", "d"}, {"c", "b"}, {"b", "a"}}}
+	if u := r.Undo(); !reflect.DeepEqual(u, want) {
+		t.Errorf("got %+v, want %+v", u, want)
+	}
+	if u := r.Undo().Undo(); !reflect.DeepEqual(u, r) {
+		t.Errorf("undoing the undo: got %+v, want %+v", u, r)
+	}
+}
+
+func TestRenamePlanApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		renames []Rename
+		want    map[string]string
+		err     bool
+	}{
+		{
+			"swap",
+			[]string{"a", "b", "c"},
+			[]Rename{{"a", "b"}, {"b", "a"}},
+			map[string]string{"a": "b", "b": "a", "c": "c"},
+			false,
+		},
+		{
+			"shift",
+			[]string{"a", "b"},
+			[]Rename{{"a", "b"}, {"b", "c"}},
+			map[string]string{"b": "a", "c": "b"},
+			false,
+		},
+		{
+			"rolled back",
+			[]string{"a", "b"},
+			[]Rename{{"a", "c"}, {"b", "missing/d"}},
+			map[string]string{"a": "a", "b": "b"},
+			true,
+		},
+	}
+
+	for _, test := range tests {
+		dir := testDir(t, test.files...)
+		r := RenamePlan{Dir: dir, Renames: test.renames}
+		manifest := r.ManifestPath("test")
+		err := r.Apply(manifest)
+		if test.err && err == nil {
+			t.Errorf("%s: expected error", test.name)
+		} else if !test.err && err != nil {
+			t.Errorf("%s: %s", test.name, err)
+		}
+
+		got := contents(t, dir)
+		_, hasManifest := got[filepath.Base(manifest)]
+		delete(got, filepath.Base(manifest))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+		if hasManifest == test.err {
+			t.Errorf("%s: manifest written: %t", test.name, hasManifest)
+		}
+		if err != nil {
+			continue
+		}
+
+		m, err := ReadRenameManifest(manifest)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if err := m.Undo().Apply(""); err != nil {
+			t.Errorf("%s: undo: %s", test.name, err)
+			continue
+		}
+		got = contents(t, dir)
+		delete(got, filepath.Base(manifest))
+		if len(got) != len(test.files) {
+			t.Errorf("%s: undo: %d files, want %d", test.name, len(got), len(test.files))
+		}
+		for name, content := range got {
+			if name != content || !slices.Contains(test.files, name) {
+				t.Errorf("%s: undo: %s contains %s", test.name, name, content)
+			}
+		}
+	}
+}
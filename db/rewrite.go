@@ -0,0 +1,109 @@
+package db
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Rewrite is a set of line based modifications of a log file.
+type Rewrite struct {
+	insert  map[uint][]string
+	replace map[uint]string
+	append  map[uint][]string
+}
+
+func NewRewrite() *Rewrite {
+	return &Rewrite{make(map[uint][]string), make(map[uint]string), make(map[uint][]string)}
+}
+
+// Insert adds lines after the given line number.
+func (r *Rewrite) Insert(after uint, lines ...string) {
+	r.insert[after] = append(r.insert[after], lines...)
+}
+
+// Replace replaces the given line number.
+func (r *Rewrite) Replace(line uint, text string) {
+	r.replace[line] = text
+}
+
+// Append adds space separated tokens to the end of the given line number.
+func (r *Rewrite) Append(line uint, tokens ...string) {
+	r.append[line] = append(r.append[line], tokens...)
+}
+
+func (r *Rewrite) Empty() bool {
+	return len(r.insert) == 0 && len(r.replace) == 0 && len(r.append) == 0
+}
+
+// Apply copies in to out with all modifications applied.
+func (r *Rewrite) Apply(in io.Reader, out io.Writer) error {
+	w := bufio.NewWriter(out)
+	write := func(l string) {
+		w.WriteString(l)
+		w.WriteByte('\n')
+	}
+	for _, l := range r.insert[0] {
+		write(l)
+	}
+
+	s := bufio.NewScanner(in)
+	s.Split(bufio.ScanLines)
+	var line uint
+	for s.Scan() {
+		line++
+		text, ok := r.replace[line]
+		if !ok {
+			text = s.Text()
+		}
+		if l := r.append[line]; len(l) != 0 {
+			text = strings.TrimRight(text, " \t") + " " + strings.Join(l, " ")
+		}
+		write(text)
+		for _, l := range r.insert[line] {
+			write(l)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
+
+// WriteFile applies the modifications to the file at path, replacing it
+// once fully written.
+func (r *Rewrite) WriteFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	stat, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".rolls-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := r.Apply(in, tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(stat.Mode()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRewrite(t *testing.T) {
+	const in = "one\ntwo  \nthree\n"
+	tests := []struct {
+		name string
+		edit func(r *Rewrite)
+		want string
+	}{
+		{"none", func(r *Rewrite) {}, in},
+		{
+			"insert",
+			func(r *Rewrite) {
+				r.Insert(0, "zero")
+				r.Insert(2, "a", "b")
+				r.Insert(2, "c")
+			},
+			"zero\none\ntwo  \na\nb\nc\nthree\n",
+		},
+		{
+			"replace",
+			func(r *Rewrite) { r.Replace(3, "3") },
+			"one\ntwo  \n3\n",
+		},
+		{
+			"append",
+			func(r *Rewrite) {
+				r.Append(2, "x=1")
+				r.Append(2, "y")
+			},
+			"one\ntwo x=1 y\nthree\n",
+		},
+		{
+			"replace and append",
+			func(r *Rewrite) {
+				r.Replace(1, "1")
+				r.Append(1, "x")
+				r.Insert(1, "1.5")
+			},
+			"1 x\n1.5\ntwo  \nthree\n",
+		},
+		{
+			"past end",
+			func(r *Rewrite) { r.Insert(10, "lost") },
+			in,
+		},
+	}
+
+	for _, test := range tests {
+		r := NewRewrite()
+		test.edit(r)
+		var out strings.Builder
+		if err := r.Apply(strings.NewReader(in), &out); err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if out.String() != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, out.String(), test.want)
+		}
+	}
+}
+
+func TestRewriteWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rolls.log")
+	if err := os.WriteFile(path, []byte("one\ntwo\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRewrite()
+	r.Append(1, "x")
+	if err := r.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "one x\ntwo\n" {
+		t.Errorf("got %q", b)
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat.Mode().Perm() != 0o600 {
+		t.Errorf("mode changed to %s", stat.Mode())
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temporary file left behind: %d files", len(entries))
+	}
+}
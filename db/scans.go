@@ -0,0 +1,202 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/frizinak/film-rolls/table"
+)
+
+// ScanFolder is the scan folder of a developed roll.
+type ScanFolder struct {
+	Entry *Entry
+	ID    string
+	// Name is the expected folder name, empty if it couldn't be determined.
+	Name string
+	// Path is the existing folder, empty if missing.
+	Path   string
+	Images int
+	// Reason is set when the name couldn't be determined.
+	Reason string
+}
+
+// Scans links the scan folders in a root directory to developed rolls.
+type Scans struct {
+	Root    string
+	Rolls   []ScanFolder
+	Orphans []string
+}
+
+// scanDirMatch reports whether dir is the folder named name, optionally
+// followed by a description, e.g. "0012 Vietnam".
+func scanDirMatch(dir, name string) bool {
+	rest, ok := strings.CutPrefix(dir, name)
+	return ok && (rest == "" || strings.ContainsAny(rest[:1], " -_."))
+}
+
+// Scans returns the scan folders of all developed rolls, named by pattern,
+// and the folders in root that don't belong to any roll. A missing root is
+// treated as empty.
+func (db *DB) Scans(root string, pattern Pattern) (Scans, error) {
+	s := Scans{Root: root}
+	entries, err := os.ReadDir(root)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return s, err
+	}
+	dirs := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			dirs = append(dirs, e.Name())
+		}
+	}
+
+	used := make(map[string]struct{}, len(dirs))
+	var i int
+	db.row("", func(e Entry, id string, active bool) {
+		entry := &db.Entries[i]
+		i++
+		if e.LabOutDate.IsZero() {
+			return
+		}
+
+		f := ScanFolder{Entry: entry, ID: id}
+		name, err := pattern.Expand(e.Vars(id))
+		if err != nil {
+			f.Reason = err.Error()
+			s.Rolls = append(s.Rolls, f)
+			return
+		}
+		f.Name = name
+		match := ""
+		for _, d := range dirs {
+			if d == f.Name {
+				match = d
+				break
+			}
+			if match == "" && scanDirMatch(d, f.Name) {
+				match = d
+			}
+		}
+		if match != "" {
+			used[match] = struct{}{}
+			f.Path = filepath.Join(root, match)
+		}
+		s.Rolls = append(s.Rolls, f)
+	})
+
+	for n := range s.Rolls {
+		f := &s.Rolls[n]
+		if f.Path == "" {
+			continue
+		}
+		images, err := Images(f.Path)
+		if err != nil {
+			return s, err
+		}
+		f.Images = len(images)
+	}
+
+	for _, d := range dirs {
+		if _, ok := used[d]; !ok {
+			s.Orphans = append(s.Orphans, filepath.Join(root, d))
+		}
+	}
+
+	return s, nil
+}
+
+func (s Scans) Tables(conf TableConfig) []*table.Table {
+	style := conf.style
+	t := table.New()
+	if conf.Header {
+		for _, h := range []string{"ID", "Date", "Stock", "Camera", "Scan", "Folder", "Images"} {
+			t.AddHeadCol(table.TermStr(h))
+		}
+	}
+	for _, f := range s.Rolls {
+		e := f.Entry
+		var folder table.Col = table.TermStr(f.Path)
+		switch {
+		case f.Reason != "":
+			folder = style(table.TermStr(f.Reason), conf.Theme.Warn)
+		case f.Path == "":
+			folder = style(table.TermStr("missing: "+filepath.Join(s.Root, f.Name)), conf.Theme.Warn)
+		}
+		scan := ""
+		if e.Scan != 0 {
+			scan = fmt.Sprintf("%04d", e.Scan)
+		}
+
+		t.NewRow()
+		t.AddCol(table.ColFixed(table.TermStr(f.ID)))
+		t.AddCol(table.ColFixed(table.TermStr(e.LoadDate.Format(dateFormat))))
+		t.AddCol(style(table.TermStr(e.Stock.Short()), conf.Theme.Stock))
+		t.AddCol(style(table.TermStr(e.Camera.Short()), conf.Theme.Camera))
+		t.AddCol(table.ColFixed(table.TermStr(scan)))
+		t.AddCol(folder)
+		t.AddCol(table.ColFixed(table.ColAlignRight(table.TermStr(strconv.Itoa(f.Images)))))
+	}
+
+	if len(s.Orphans) == 0 {
+		return []*table.Table{t}
+	}
+
+	o := table.New()
+	if conf.Header {
+		o.AddHeadCol(table.TermStr("Folder without roll"))
+	}
+	for _, d := range s.Orphans {
+		o.NewRow()
+		o.AddCol(style(table.TermStr(d), conf.Theme.Warn))
+	}
+	return []*table.Table{t, o}
+}
+
+func (s Scans) Print(w io.Writer, conf TableConfig) error {
+	return conf.renderAll(w, s.Tables(conf))
+}
+
+// Scaffold creates the missing scan folders of developed rolls and returns
+// their paths, or only returns them when dryRun is set.
+func (s Scans) Scaffold(dryRun bool) ([]string, error) {
+	l := make([]string, 0)
+	for _, f := range s.Rolls {
+		if f.Path != "" || f.Name == "" {
+			continue
+		}
+		dir := filepath.Join(s.Root, f.Name)
+		l = append(l, dir)
+		if dryRun {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return l, err
+		}
+	}
+	return l, nil
+}
+
+// PrintScaffold reports the created folders and the rolls whose folder name
+// couldn't be determined.
+func PrintScaffold(w io.Writer, s Scans, created []string) error {
+	for _, f := range s.Rolls {
+		if f.Reason == "" {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "skip   %s: %s\n", f.ID, f.Reason); err != nil {
+			return err
+		}
+	}
+	for _, d := range created {
+		if _, err := fmt.Fprintf(w, "create %s\n", d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package db
+
+import (
+	"cmp"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/frizinak/film-rolls/table"
+)
+
+// ShoppingWindow is the period used to compute the recent consumption rate.
+const ShoppingWindow = 180 * 24 * time.Hour
+
+// Reorder is a stock that is at or below its reorder threshold.
+type Reorder struct {
+	Stock     *Stock
+	Available int
+	Threshold int
+	// PerMonth is the amount of rolls loaded per 30 days within the
+	// ShoppingWindow before now.
+	PerMonth float64
+	// RunsOut is the forecast date the stock runs out, zero when the stock
+	// isn't being used.
+	RunsOut time.Time
+}
+
+// Shopping returns the stocks whose available amount of rolls is at or below
+// their threshold as of now, sorted by their forecast run out date. The
+// threshold of the stock definition takes precedence over thresholds.
+func (db *DB) Shopping(now time.Time, thresholds map[ID]int) []Reorder {
+	since := now.Add(-ShoppingWindow)
+	loaded := make(map[ID]int)
+	for _, e := range db.Entries {
+		if e.LoadDate.After(since) && !e.LoadDate.After(now) {
+			loaded[e.Stock.ID]++
+		}
+	}
+
+	l := make([]Reorder, 0)
+	for id, i := range db.inventory(now) {
+		th, ok := thresholds[id]
+		if i.Stock.Reorder != 0 {
+			th, ok = i.Stock.Reorder, true
+		}
+		if !ok || i.Available() > th {
+			continue
+		}
+		r := Reorder{Stock: i.Stock, Available: i.Available(), Threshold: th}
+		if n := loaded[id]; n != 0 {
+			perDay := float64(n) / ShoppingWindow.Hours() * 24
+			r.PerMonth = perDay * 30
+			r.RunsOut = now
+			if r.Available > 0 {
+				r.RunsOut = now.AddDate(0, 0, int(float64(r.Available)/perDay))
+			}
+		}
+		l = append(l, r)
+	}
+
+	slices.SortFunc(l, func(a, b Reorder) int {
+		switch {
+		case a.RunsOut.IsZero() && !b.RunsOut.IsZero():
+			return 1
+		case !a.RunsOut.IsZero() && b.RunsOut.IsZero():
+			return -1
+		}
+		if c := a.RunsOut.Compare(b.RunsOut); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Stock.ID, b.Stock.ID)
+	})
+
+	return l
+}
+
+func (db *DB) ShoppingTable(conf TableConfig, thresholds map[ID]int) *table.Table {
+	t := table.New()
+	style := conf.style
+	if conf.Header {
+		for _, h := range []string{
+			"Avail", "Reorder at", "Per month", "Runs out",
+			"SID", "Manufacturer", "Stock", "Format", "ISO",
+		} {
+			t.AddHeadCol(table.TermStr(h))
+		}
+	}
+
+	now := conf.At
+	if now.IsZero() {
+		now = time.Now()
+	}
+	for _, r := range db.Shopping(now, thresholds) {
+		var avail table.Col = table.TermStr(strconv.Itoa(r.Available))
+		if r.Available <= 0 {
+			avail = style(avail, conf.Theme.Warn)
+		}
+		runsOut := ""
+		if !r.RunsOut.IsZero() {
+			runsOut = r.RunsOut.Format(dateFormat)
+		}
+
+		t.NewRow()
+		t.AddCol(table.ColFixed(table.ColAlignRight(avail)))
+		t.AddCol(table.ColFixed(table.ColAlignRight(table.TermStr(strconv.Itoa(r.Threshold)))))
+		t.AddCol(table.ColFixed(table.ColAlignRight(table.TermStr(strconv.FormatFloat(r.PerMonth, 'f', 1, 64)))))
+		t.AddCol(table.ColFixed(table.TermStr(runsOut)))
+		t.AddCol(table.ColFixed(style(table.TermStr(r.Stock.ID.String()), conf.Theme.ID)))
+		t.AddCol(table.ColJoined(table.ColFixed(style(table.TermStr(r.Stock.Company.Name), conf.Theme.Stock))))
+		t.AddCol(table.ColJoined(table.ColFixed(style(table.TermStr(r.Stock.Name), conf.Theme.Stock))))
+		t.AddCol(table.ColJoined(table.ColFixed(table.TermStr(r.Stock.Format))))
+		t.AddCol(table.ColJoined(table.ColFixed(table.TermStr(r.Stock.ISO.String()))))
+	}
+
+	return t
+}
+
+func (db *DB) PrintShopping(w io.Writer, conf TableConfig, thresholds map[ID]int) error {
+	return conf.render(w, db.ShoppingTable(conf, thresholds))
+}
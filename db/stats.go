@@ -0,0 +1,256 @@
+package db
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/frizinak/film-rolls/table"
+)
+
+type Count struct {
+	Key   string `json:"key"`
+	Rolls int    `json:"rolls"`
+}
+
+type Days struct {
+	Rolls   int     `json:"rolls"`
+	Average float64 `json:"average"`
+	Median  float64 `json:"median"`
+}
+
+func mkDays(l []float64) Days {
+	d := Days{Rolls: len(l)}
+	if len(l) == 0 {
+		return d
+	}
+
+	slices.Sort(l)
+	var sum float64
+	for _, v := range l {
+		sum += v
+	}
+	d.Average = sum / float64(len(l))
+	d.Median = l[len(l)/2]
+	if len(l)%2 == 0 {
+		d.Median = (l[len(l)/2-1] + l[len(l)/2]) / 2
+	}
+
+	return d
+}
+
+type Stats struct {
+	Rolls int `json:"rolls"`
+
+	PerYear    []Count `json:"per_year"`
+	PerMonth   []Count `json:"per_month"`
+	PerCamera  []Count `json:"per_camera"`
+	PerStock   []Count `json:"per_stock"`
+	PerCompany []Count `json:"per_company"`
+	PerFormat  []Count `json:"per_format"`
+	PerType    []Count `json:"per_type"`
+	PerProcess []Count `json:"per_process"`
+
+	PerLens       []Count `json:"per_lens"`
+	PerLensCamera []Count `json:"per_lens_camera"`
+	PerLensStock  []Count `json:"per_lens_stock"`
+
+	InCamera Days `json:"days_in_camera"`
+	AtLab    Days `json:"days_at_lab"`
+
+	BusiestMonth Count `json:"busiest_month"`
+}
+
+// unloadDates returns the date each entry was removed from its camera,
+// i.e.: the earliest of the lab-in date and the next load in the same camera.
+// Rolls that are still loaded have a zero time, as do removed rolls without
+// a known end, see unknownEnd.
+func (db *DB) unloadDates() []time.Time {
+	dates := make([]time.Time, len(db.Entries))
+	cams := make(map[ID][]int)
+	for i, e := range db.Entries {
+		cams[e.Camera.ID] = append(cams[e.Camera.ID], i)
+		dates[i] = e.LabInDate
+	}
+
+	for _, l := range cams {
+		slices.SortStableFunc(l, func(i, j int) int {
+			return db.Entries[i].LoadDate.Compare(db.Entries[j].LoadDate)
+		})
+		for n := 0; n < len(l)-1; n++ {
+			next := db.Entries[l[n+1]].LoadDate
+			if dates[l[n]].IsZero() || next.Before(dates[l[n]]) {
+				dates[l[n]] = next
+			}
+		}
+	}
+
+	return dates
+}
+
+// unknownEnd reports whether the entry with the given unload date left its
+// camera at an unknown date.
+func unknownEnd(e *Entry, unload time.Time) bool {
+	return unload.IsZero() && e.Lab != nil
+}
+
+func days(from, to time.Time) float64 {
+	return to.Sub(from).Hours() / 24
+}
+
+func (db *DB) Stats() Stats {
+	var s Stats
+	year := make(map[string]int)
+	month := make(map[string]int)
+	camera := make(map[string]int)
+	stock := make(map[string]int)
+	company := make(map[string]int)
+	format := make(map[string]int)
+	typ := make(map[string]int)
+	process := make(map[string]int)
+	lens := make(map[string]int)
+	lensCamera := make(map[string]int)
+	lensStock := make(map[string]int)
+
+	unload := db.unloadDates()
+	inCamera := make([]float64, 0, len(db.Entries))
+	atLab := make([]float64, 0, len(db.Entries))
+
+	for i, e := range db.Entries {
+		s.Rolls++
+		year[e.LoadDate.Format("2006")]++
+		month[e.LoadDate.Format("2006-01")]++
+		camera[e.Camera.String()]++
+		stock[e.Stock.String()]++
+		company[e.Stock.Company.String()]++
+		format[e.Stock.Format]++
+		typ[e.Stock.Type.String()]++
+		process[e.Stock.Process.String()]++
+		for _, l := range e.Lenses {
+			lens[l.String()]++
+			lensCamera[fmt.Sprintf("%s / %s", l.String(), e.Camera.String())]++
+			lensStock[fmt.Sprintf("%s / %s", l.String(), e.Stock.String())]++
+		}
+
+		if !unload[i].IsZero() {
+			inCamera = append(inCamera, days(e.LoadDate, unload[i]))
+		}
+		if !e.LabInDate.IsZero() && !e.LabOutDate.IsZero() {
+			atLab = append(atLab, days(e.LabInDate, e.LabOutDate))
+		}
+	}
+
+	chrono := func(m map[string]int) []Count {
+		l := make([]Count, 0, len(m))
+		for k, v := range m {
+			l = append(l, Count{k, v})
+		}
+		slices.SortFunc(l, func(a, b Count) int { return cmp.Compare(a.Key, b.Key) })
+		return l
+	}
+	ranked := func(m map[string]int) []Count {
+		l := chrono(m)
+		slices.SortStableFunc(l, func(a, b Count) int { return cmp.Compare(b.Rolls, a.Rolls) })
+		return l
+	}
+
+	s.PerYear = chrono(year)
+	s.PerMonth = chrono(month)
+	s.PerCamera = ranked(camera)
+	s.PerStock = ranked(stock)
+	s.PerCompany = ranked(company)
+	s.PerFormat = ranked(format)
+	s.PerType = ranked(typ)
+	s.PerProcess = ranked(process)
+	s.PerLens = ranked(lens)
+	s.PerLensCamera = ranked(lensCamera)
+	s.PerLensStock = ranked(lensStock)
+	s.InCamera = mkDays(inCamera)
+	s.AtLab = mkDays(atLab)
+	if l := ranked(month); len(l) != 0 {
+		s.BusiestMonth = l[0]
+	}
+
+	return s
+}
+
+func (db *DB) StatsTables(conf TableConfig) []*table.Table {
+	s := db.Stats()
+	style := conf.style
+	tables := make([]*table.Table, 0, 12)
+
+	counts := func(title string, l []Count) {
+		t := table.New()
+		if conf.Header {
+			t.AddHeadCol(table.TermStr(title))
+			t.AddHeadCol(table.TermStr("Rolls"))
+		}
+		for _, c := range l {
+			t.NewRow()
+			t.AddCol(table.ColFixed(style(table.TermStr(c.Key), conf.Theme.Stock)))
+			t.AddCol(table.ColFixed(table.ColAlignRight(table.TermStr(strconv.Itoa(c.Rolls)))))
+		}
+		tables = append(tables, t)
+	}
+
+	num := func(f float64) string { return strconv.FormatFloat(f, 'f', 1, 64) }
+
+	t := table.New()
+	if conf.Header {
+		t.AddHeadCol(table.TermStr("Summary"))
+		t.AddHeadCol(table.TermStr("Rolls"))
+		t.AddHeadCol(table.TermStr("Average"))
+		t.AddHeadCol(table.TermStr("Median"))
+	}
+	t.AddRow(
+		table.ColFixed(table.TermStr("Total")),
+		table.ColFixed(table.ColAlignRight(table.TermStr(strconv.Itoa(s.Rolls)))),
+	)
+	t.AddRow(
+		table.ColFixed(table.TermStr(fmt.Sprintf("Busiest month: %s", s.BusiestMonth.Key))),
+		table.ColFixed(table.ColAlignRight(table.TermStr(strconv.Itoa(s.BusiestMonth.Rolls)))),
+	)
+	for _, d := range []struct {
+		title string
+		Days
+	}{
+		{"Days in camera", s.InCamera},
+		{"Days at lab", s.AtLab},
+	} {
+		t.AddRow(
+			table.ColFixed(table.TermStr(d.title)),
+			table.ColFixed(table.ColAlignRight(table.TermStr(strconv.Itoa(d.Rolls)))),
+			table.ColFixed(table.ColAlignRight(table.TermStr(num(d.Average)))),
+			table.ColFixed(table.ColAlignRight(table.TermStr(num(d.Median)))),
+		)
+	}
+	tables = append(tables, t)
+
+	counts("Year", s.PerYear)
+	counts("Month", s.PerMonth)
+	counts("Camera", s.PerCamera)
+	counts("Stock", s.PerStock)
+	counts("Manufacturer", s.PerCompany)
+	counts("Format", s.PerFormat)
+	counts("Type", s.PerType)
+	counts("Process", s.PerProcess)
+	counts("Lens", s.PerLens)
+	counts("Lens / Camera", s.PerLensCamera)
+	counts("Lens / Stock", s.PerLensStock)
+
+	return tables
+}
+
+func (db *DB) PrintStats(w io.Writer, conf TableConfig) error {
+	return conf.renderAll(w, db.StatsTables(conf))
+}
+
+func (db *DB) PrintStatsJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(db.Stats())
+}
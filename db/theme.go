@@ -0,0 +1,145 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Style is a list of SGR parameters, e.g.: "1;32" for bold green.
+type Style string
+
+var styleNames = map[string]string{
+	"bold":      "1",
+	"dim":       "2",
+	"italic":    "3",
+	"underline": "4",
+	"reverse":   "7",
+
+	"black":   "30",
+	"red":     "31",
+	"green":   "32",
+	"yellow":  "33",
+	"blue":    "34",
+	"magenta": "35",
+	"cyan":    "36",
+	"white":   "37",
+
+	"bright-black":   "90",
+	"bright-red":     "91",
+	"bright-green":   "92",
+	"bright-yellow":  "93",
+	"bright-blue":    "94",
+	"bright-magenta": "95",
+	"bright-cyan":    "96",
+	"bright-white":   "97",
+}
+
+// ParseStyle parses a space separated list of attributes (bold, dim, italic,
+// underline, reverse), color names (red, bright-red, ...) or 256 color
+// palette indices. Colors prefixed with "bg:" set the background.
+func ParseStyle(str string) (Style, error) {
+	fields := strings.Fields(str)
+	seq := make([]string, 0, len(fields))
+	for _, f := range fields {
+		bg := strings.HasPrefix(f, "bg:")
+		f = strings.TrimPrefix(f, "bg:")
+		if n, err := strconv.ParseUint(f, 10, 8); err == nil {
+			p := "38;5;"
+			if bg {
+				p = "48;5;"
+			}
+			seq = append(seq, p+strconv.Itoa(int(n)))
+			continue
+		}
+
+		v, ok := styleNames[f]
+		if !ok {
+			return "", fmt.Errorf("invalid style '%s'", f)
+		}
+		if bg {
+			n, _ := strconv.Atoi(v)
+			if n < 30 {
+				return "", fmt.Errorf("invalid background color '%s'", f)
+			}
+			v = strconv.Itoa(n + 10)
+		}
+		seq = append(seq, v)
+	}
+
+	return Style(strings.Join(seq, ";")), nil
+}
+
+func (s *Style) UnmarshalText(b []byte) error {
+	st, err := ParseStyle(string(b))
+	*s = st
+	return err
+}
+
+func (s Style) Prefix() string {
+	if s == "" {
+		return ""
+	}
+	return "\033[" + string(s) + "m"
+}
+
+func (s Style) Suffix() string {
+	if s == "" {
+		return ""
+	}
+	return "\033[0m"
+}
+
+// Theme assigns styles to the different kinds of table columns.
+type Theme struct {
+	ID     Style `json:"id"`
+	Stock  Style `json:"stock"`
+	Camera Style `json:"camera"`
+	Active Style `json:"active"`
+	// AtLab marks the lab of rolls that are being developed.
+	AtLab Style `json:"at_lab"`
+	Lab   Style `json:"lab"`
+	Group Style `json:"group"`
+	Warn  Style `json:"warn"`
+}
+
+const (
+	ThemeDark       = "dark"
+	ThemeLight      = "light"
+	ThemeMonochrome = "monochrome"
+)
+
+var themes = map[string]Theme{
+	ThemeDark: {
+		ID:     "38;5;244",
+		Stock:  "32",
+		Active: "31",
+		AtLab:  "36",
+		Group:  "1;4",
+		Warn:   "33",
+	},
+	ThemeLight: {
+		ID:     "38;5;242",
+		Stock:  "38;5;22",
+		Camera: "38;5;236",
+		Active: "1;31",
+		AtLab:  "1;38;5;24",
+		Lab:    "38;5;24",
+		Group:  "1;38;5;236",
+		Warn:   "38;5;130",
+	},
+	ThemeMonochrome: {
+		ID:     "2",
+		Stock:  "1",
+		Active: "7",
+		AtLab:  "4",
+		Group:  "1;4",
+		Warn:   "1",
+	},
+}
+
+// BuiltinTheme returns the builtin theme with the given name.
+func BuiltinTheme(name string) (Theme, bool) {
+	t, ok := themes[name]
+	return t, ok
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/frizinak/film-rolls/chart"
+)
+
+// Lanes returns a lane per camera, with a span per roll from its load date
+// until it left the camera, see unloadDates. Rolls that are still loaded are
+// open ended at now, removed rolls without a known end last a day.
+func (db *DB) Lanes(now time.Time) []chart.Lane {
+	unload := db.unloadDates()
+	cams := make(map[ID][]int)
+	for i, e := range db.Entries {
+		cams[e.Camera.ID] = append(cams[e.Camera.ID], i)
+	}
+
+	ids := make([]ID, 0, len(cams))
+	for id := range cams {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a, b ID) int { return cmp.Compare(a, b) })
+
+	lanes := make([]chart.Lane, 0, len(ids))
+	for _, id := range ids {
+		entries := cams[id]
+		slices.SortStableFunc(entries, func(a, b int) int {
+			return db.Entries[a].LoadDate.Compare(db.Entries[b].LoadDate)
+		})
+
+		lane := chart.Lane{Label: db.Entries[entries[0]].Camera.String()}
+		for _, i := range entries {
+			e := &db.Entries[i]
+			s := chart.Span{
+				From:  e.LoadDate,
+				To:    unload[i],
+				Label: string(e.Stock.ID),
+			}
+			if s.To.IsZero() {
+				s.To = now
+				s.Open = !unknownEnd(e, unload[i])
+				if !s.Open {
+					s.To = e.LoadDate.AddDate(0, 0, 1)
+				}
+			}
+			if !s.To.After(s.From) {
+				s.To = s.From.AddDate(0, 0, 1)
+			}
+
+			s.Title = fmt.Sprintf(
+				"%s %s - %s",
+				e.Stock.String(),
+				e.LoadDate.Format(dateFormat),
+				s.To.Format(dateFormat),
+			)
+			lane.Spans = append(lane.Spans, s)
+		}
+		lanes = append(lanes, lane)
+	}
+
+	return lanes
+}
+
+func (db *DB) PrintTimeline(w io.Writer, conf TableConfig) error {
+	width := conf.Width
+	if width == 0 {
+		width = 80
+	}
+
+	var style chart.Style
+	if conf.Color {
+		style = chart.Style{Prefix: conf.Theme.Stock.Prefix(), Suffix: conf.Theme.Stock.Suffix()}
+	}
+
+	return chart.Timeline(w, db.Lanes(time.Now().UTC()), width, style)
+}
+
+func (db *DB) PrintTimelineSVG(w io.Writer, width int) error {
+	return chart.TimelineSVG(w, db.Lanes(time.Now().UTC()), width)
+}
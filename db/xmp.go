@@ -0,0 +1,177 @@
+package db
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// XMPStyle is the naming convention of sidecar files.
+type XMPStyle string
+
+const (
+	// XMPLightroom replaces the image extension: image.xmp.
+	XMPLightroom XMPStyle = "lightroom"
+	// XMPDarktable appends to the image name: image.jpg.xmp.
+	XMPDarktable XMPStyle = "darktable"
+)
+
+func (s XMPStyle) Sidecar(image string) string {
+	if s == XMPDarktable {
+		return image + ".xmp"
+	}
+	return strings.TrimSuffix(image, filepath.Ext(image)) + ".xmp"
+}
+
+func xmlEscape(str string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(str))
+	return b.String()
+}
+
+// gpsCoord formats a coordinate as the XMP exif DDD,MM.mmmmR format.
+func gpsCoord(v float64, pos, neg string) string {
+	ref := pos
+	if v < 0 {
+		ref, v = neg, -v
+	}
+	deg := math.Floor(v)
+	return fmt.Sprintf("%d,%.6f%s", int(deg), (v-deg)*60, ref)
+}
+
+// WriteXMP writes m as an XMP packet.
+func (m Metadata) WriteXMP(w io.Writer) error {
+	b := bytes.NewBuffer(nil)
+	attr := func(k, v string) {
+		if v != "" {
+			fmt.Fprintf(b, "\n    %s=\"%s\"", k, xmlEscape(v))
+		}
+	}
+	list := func(tag, kind string, l []string) {
+		if len(l) == 0 {
+			return
+		}
+		fmt.Fprintf(b, "   <%s>\n    <rdf:%s>\n", tag, kind)
+		for _, v := range l {
+			if kind == "Alt" {
+				fmt.Fprintf(b, "     <rdf:li xml:lang=\"x-default\">%s</rdf:li>\n", xmlEscape(v))
+				continue
+			}
+			fmt.Fprintf(b, "     <rdf:li>%s</rdf:li>\n", xmlEscape(v))
+		}
+		fmt.Fprintf(b, "    </rdf:%s>\n   </%s>\n", kind, tag)
+	}
+
+	b.WriteString("<?xpacket begin=\"\uFEFF\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n")
+	b.WriteString(" <rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">\n")
+	b.WriteString("  <rdf:Description rdf:about=\"\"")
+	attr("xmlns:tiff", "http://ns.adobe.com/tiff/1.0/")
+	attr("xmlns:exif", "http://ns.adobe.com/exif/1.0/")
+	attr("xmlns:aux", "http://ns.adobe.com/exif/1.0/aux/")
+	attr("xmlns:xmp", "http://ns.adobe.com/xap/1.0/")
+	attr("xmlns:dc", "http://purl.org/dc/elements/1.1/")
+	attr("xmlns:lr", "http://ns.adobe.com/lightroom/1.0/")
+	attr("tiff:Make", m.Make)
+	attr("tiff:Model", m.Model)
+	attr("aux:Lens", m.Lens)
+	if !m.Date.IsZero() {
+		attr("exif:DateTimeOriginal", m.Date.Format("2006-01-02T15:04:05"))
+		attr("xmp:CreateDate", m.Date.Format("2006-01-02T15:04:05"))
+	}
+	if m.Geo != nil {
+		attr("exif:GPSLatitude", gpsCoord(m.Geo.Lat, "N", "S"))
+		attr("exif:GPSLongitude", gpsCoord(m.Geo.Lon, "E", "W"))
+	}
+	b.WriteString(">\n")
+	if m.ISO != 0 {
+		list("exif:ISOSpeedRatings", "Seq", []string{fmt.Sprint(m.ISO)})
+	}
+	if m.Description != "" {
+		list("dc:description", "Alt", []string{m.Description})
+	}
+	list("dc:subject", "Bag", m.Keywords)
+	list("lr:hierarchicalSubject", "Bag", m.Hierarchy)
+	b.WriteString("  </rdf:Description>\n </rdf:RDF>\n</x:xmpmeta>\n<?xpacket end=\"w\"?>\n")
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// SidecarResult is the outcome of writing a single sidecar.
+type SidecarResult struct {
+	Image   string
+	Sidecar string
+	// Skipped is set when an existing sidecar was left untouched.
+	Skipped bool
+}
+
+// WriteSidecars writes an XMP sidecar with the metadata of the roll next to
+// each image in dir. Existing sidecars are only replaced when overwrite is
+// set. Images sharing a name with a different extension, e.g.: image.jpg and
+// image.tif, would share a lightroom sidecar, all but the first get a
+// darktable sidecar instead.
+func (db *DB) WriteSidecars(id, dir string, style XMPStyle, overwrite, dryRun bool) ([]SidecarResult, error) {
+	e, err := db.Roll(id)
+	if err != nil {
+		return nil, err
+	}
+	images, err := Images(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	m := e.Metadata(id)
+	l := make([]SidecarResult, 0, len(images))
+	used := make(map[string]struct{}, len(images))
+	for _, img := range images {
+		r := SidecarResult{Image: img, Sidecar: style.Sidecar(img)}
+		if _, ok := used[r.Sidecar]; ok {
+			r.Sidecar = XMPDarktable.Sidecar(img)
+		}
+		used[r.Sidecar] = struct{}{}
+		if _, err := os.Stat(r.Sidecar); err == nil && !overwrite {
+			r.Skipped = true
+		} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return l, err
+		}
+		l = append(l, r)
+		if r.Skipped || dryRun {
+			continue
+		}
+
+		f, err := os.Create(r.Sidecar)
+		if err != nil {
+			return l, err
+		}
+		err = m.WriteXMP(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return l, err
+		}
+	}
+
+	return l, nil
+}
+
+func PrintSidecars(w io.Writer, l []SidecarResult) error {
+	for _, r := range l {
+		status := "write"
+		if r.Skipped {
+			status = "exists"
+		}
+		if _, err := fmt.Fprintf(w, "%-6s %s\n", status, r.Sidecar); err != nil {
+			return err
+		}
+	}
+	return nil
+}
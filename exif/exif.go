@@ -0,0 +1,476 @@
+// Package exif embeds metadata in JPEG and TIFF files.
+//
+// Existing metadata is kept. The Exif block of a JPEG is rebuilt from the
+// merged entries, TIFF files get the merged IFDs appended, leaving all
+// existing offsets, e.g.: to image data, valid. Offsets inside maker notes
+// aren't rewritten.
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+)
+
+// Tags is the metadata to embed, zero values are left untouched.
+type Tags struct {
+	Make        string
+	Model       string
+	Description string
+	Lens        string
+	ISO         uint16
+	// DateTimeOriginal is written as is, without a time zone.
+	DateTimeOriginal time.Time
+	GPS              *GPS
+	// XMP is a complete XMP packet.
+	XMP []byte
+}
+
+type GPS struct {
+	Lat, Lon float64
+}
+
+const (
+	typeByte      = 1
+	typeASCII     = 2
+	typeShort     = 3
+	typeLong      = 4
+	typeRational  = 5
+	typeUndefined = 7
+
+	tagStripOffsets     = 0x0111
+	tagDescription      = 0x010E
+	tagMake             = 0x010F
+	tagModel            = 0x0110
+	tagThumbnail        = 0x0201
+	tagThumbnailLength  = 0x0202
+	tagXMP              = 0x02BC
+	tagExifIFD          = 0x8769
+	tagGPSIFD           = 0x8825
+	tagISO              = 0x8827
+	tagDateTimeOriginal = 0x9003
+	tagInteropIFD       = 0xA005
+	tagLensModel        = 0xA434
+
+	tagGPSVersion = 0x0000
+	tagGPSLatRef  = 0x0001
+	tagGPSLat     = 0x0002
+	tagGPSLonRef  = 0x0003
+	tagGPSLon     = 0x0004
+)
+
+// pointerTags point to a nested IFD.
+var pointerTags = []uint16{tagExifIFD, tagGPSIFD, tagInteropIFD}
+
+// typeSizes is the size in bytes of a single value of each type.
+var typeSizes = map[uint16]uint64{
+	1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8, 13: 4,
+}
+
+type entry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	// data is the value in the byte order of the file.
+	data []byte
+}
+
+func (e entry) equal(o entry) bool {
+	return e.tag == o.tag && e.typ == o.typ && e.count == o.count && bytes.Equal(e.data, o.data)
+}
+
+type ifd struct {
+	entries []entry
+	// subs are the nested IFDs by pointer tag.
+	subs map[uint16]*ifd
+	// next is the offset of the next IFD in the original file.
+	next uint32
+}
+
+type byteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+type tiff struct {
+	b     []byte
+	order byteOrder
+}
+
+func parseTIFF(b []byte) (*tiff, uint32, error) {
+	if len(b) < 8 {
+		return nil, 0, errors.New("invalid tiff header")
+	}
+	t := &tiff{b: b}
+	switch string(b[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, 0, errors.New("invalid tiff byte order")
+	}
+	switch t.order.Uint16(b[2:]) {
+	case 42:
+	case 43:
+		return nil, 0, errors.New("bigtiff is not supported")
+	default:
+		return nil, 0, errors.New("invalid tiff magic")
+	}
+	return t, t.order.Uint32(b[4:]), nil
+}
+
+// header returns an empty tiff with the byte order of t.
+func (t *tiff) header() *tiff {
+	h := &tiff{order: t.order}
+	h.b = append(h.b, t.b[:2]...)
+	h.b = t.order.AppendUint16(h.b, 42)
+	h.b = t.order.AppendUint32(h.b, 0)
+	return h
+}
+
+func (t *tiff) readIFD(off uint32) (*ifd, error) {
+	if uint64(off)+2 > uint64(len(t.b)) {
+		return nil, fmt.Errorf("invalid ifd offset %d", off)
+	}
+	n := uint32(t.order.Uint16(t.b[off:]))
+	end := uint64(off) + 2 + uint64(n)*12 + 4
+	if end > uint64(len(t.b)) {
+		return nil, fmt.Errorf("truncated ifd at %d", off)
+	}
+	d := &ifd{entries: make([]entry, 0, n), subs: make(map[uint16]*ifd)}
+	for i := uint32(0); i < n; i++ {
+		p := t.b[off+2+i*12:]
+		e := entry{tag: t.order.Uint16(p), typ: t.order.Uint16(p[2:]), count: t.order.Uint32(p[4:])}
+		size, ok := typeSizes[e.typ]
+		size *= uint64(e.count)
+		switch {
+		case !ok:
+			// Unknown types keep their raw value.
+			e.data = bytes.Clone(p[8:12])
+		case size <= 4:
+			e.data = bytes.Clone(p[8 : 8+size])
+		default:
+			o := uint64(t.order.Uint32(p[8:]))
+			if o+size > uint64(len(t.b)) {
+				return nil, fmt.Errorf("invalid value offset of tag %#04x", e.tag)
+			}
+			e.data = bytes.Clone(t.b[o : o+size])
+		}
+		d.entries = append(d.entries, e)
+	}
+	d.next = t.order.Uint32(t.b[end-4:])
+	return d, nil
+}
+
+// readTree reads the IFD at off and the IFDs nested in it.
+func (t *tiff) readTree(off uint32, depth int) (*ifd, error) {
+	if depth > len(pointerTags) {
+		return nil, errors.New("too deeply nested ifds")
+	}
+	d, err := t.readIFD(off)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range d.entries {
+		if !slices.Contains(pointerTags, e.tag) || len(e.data) != 4 {
+			continue
+		}
+		if d.subs[e.tag], err = t.readTree(t.order.Uint32(e.data), depth+1); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+func (t *tiff) align() {
+	if len(t.b)%2 != 0 {
+		t.b = append(t.b, 0)
+	}
+}
+
+func (t *tiff) offset() (uint32, error) {
+	if len(t.b) > math.MaxUint32 {
+		return 0, errors.New("file too large")
+	}
+	return uint32(len(t.b)), nil
+}
+
+// writeIFD appends the values that don't fit in an entry and the entries,
+// and returns the offset of the IFD.
+func (t *tiff) writeIFD(l []entry, next uint32) (uint32, error) {
+	l = slices.Clone(l)
+	slices.SortFunc(l, func(a, b entry) int { return int(a.tag) - int(b.tag) })
+	values := make([][4]byte, len(l))
+	for i := range l {
+		if len(l[i].data) <= 4 {
+			copy(values[i][:], l[i].data)
+			continue
+		}
+		t.align()
+		off, err := t.offset()
+		if err != nil {
+			return 0, err
+		}
+		t.order.PutUint32(values[i][:], off)
+		t.b = append(t.b, l[i].data...)
+	}
+
+	t.align()
+	off, err := t.offset()
+	if err != nil {
+		return 0, err
+	}
+	t.b = t.order.AppendUint16(t.b, uint16(len(l)))
+	for i, e := range l {
+		t.b = t.order.AppendUint16(t.b, e.tag)
+		t.b = t.order.AppendUint16(t.b, e.typ)
+		t.b = t.order.AppendUint32(t.b, e.count)
+		t.b = append(t.b, values[i][:]...)
+	}
+	t.b = t.order.AppendUint32(t.b, next)
+	return off, nil
+}
+
+// writeTree appends the nested IFDs of d followed by d itself.
+func (t *tiff) writeTree(d *ifd, next uint32) (uint32, error) {
+	l := slices.Clone(d.entries)
+	for i, e := range l {
+		sub, ok := d.subs[e.tag]
+		if !ok {
+			continue
+		}
+		off, err := t.writeTree(sub, 0)
+		if err != nil {
+			return 0, err
+		}
+		l[i] = t.long(e.tag, off)
+	}
+	return t.writeIFD(l, next)
+}
+
+func merge(l []entry, add []entry) []entry {
+	for _, a := range add {
+		i := slices.IndexFunc(l, func(e entry) bool { return e.tag == a.tag })
+		if i == -1 {
+			l = append(l, a)
+			continue
+		}
+		l[i] = a
+	}
+	return l
+}
+
+func (d *ifd) clone() *ifd {
+	c := &ifd{entries: slices.Clone(d.entries), subs: make(map[uint16]*ifd, len(d.subs)), next: d.next}
+	for tag, sub := range d.subs {
+		c.subs[tag] = sub.clone()
+	}
+	return c
+}
+
+func (d *ifd) equal(o *ifd) bool {
+	if len(d.entries) != len(o.entries) || len(d.subs) != len(o.subs) {
+		return false
+	}
+	for _, e := range d.entries {
+		i := slices.IndexFunc(o.entries, func(oe entry) bool { return oe.tag == e.tag })
+		if i == -1 {
+			return false
+		}
+		if _, ok := d.subs[e.tag]; !ok && !e.equal(o.entries[i]) {
+			return false
+		}
+	}
+	for tag, sub := range d.subs {
+		if osub, ok := o.subs[tag]; !ok || !sub.equal(osub) {
+			return false
+		}
+	}
+	return true
+}
+
+// setSub replaces the nested IFD of the given pointer tag.
+func (d *ifd) setSub(tag uint16, sub *ifd) {
+	d.subs[tag] = sub
+	d.entries = merge(d.entries, []entry{{tag: tag, typ: typeLong, count: 1, data: make([]byte, 4)}})
+}
+
+func (t *tiff) ascii(tag uint16, v string) entry {
+	d := append([]byte(v), 0)
+	return entry{tag: tag, typ: typeASCII, count: uint32(len(d)), data: d}
+}
+
+func (t *tiff) short(tag uint16, v uint16) entry {
+	return entry{tag: tag, typ: typeShort, count: 1, data: t.order.AppendUint16(nil, v)}
+}
+
+func (t *tiff) long(tag uint16, v uint32) entry {
+	return entry{tag: tag, typ: typeLong, count: 1, data: t.order.AppendUint32(nil, v)}
+}
+
+// degrees encodes a coordinate as degrees, minutes and seconds rationals.
+func (t *tiff) degrees(tag uint16, v float64) entry {
+	v = math.Abs(v)
+	deg := math.Floor(v)
+	min := math.Floor((v - deg) * 60)
+	sec := math.Round(((v-deg)*60 - min) * 60 * 10000)
+	var d []byte
+	for _, r := range [][2]uint32{{uint32(deg), 1}, {uint32(min), 1}, {uint32(sec), 10000}} {
+		d = t.order.AppendUint32(d, r[0])
+		d = t.order.AppendUint32(d, r[1])
+	}
+	return entry{tag: tag, typ: typeRational, count: 3, data: d}
+}
+
+// apply merges tags into IFD0 and its Exif and GPS IFDs.
+func (t *tiff) apply(ifd0 *ifd, tags Tags, xmp bool) {
+	var add, addExif []entry
+	if tags.Make != "" {
+		add = append(add, t.ascii(tagMake, tags.Make))
+	}
+	if tags.Model != "" {
+		add = append(add, t.ascii(tagModel, tags.Model))
+	}
+	if tags.Description != "" {
+		add = append(add, t.ascii(tagDescription, tags.Description))
+	}
+	if xmp && len(tags.XMP) != 0 {
+		add = append(add, entry{tag: tagXMP, typ: typeByte, count: uint32(len(tags.XMP)), data: tags.XMP})
+	}
+	if tags.ISO != 0 {
+		addExif = append(addExif, t.short(tagISO, tags.ISO))
+	}
+	if !tags.DateTimeOriginal.IsZero() {
+		addExif = append(addExif, t.ascii(tagDateTimeOriginal, tags.DateTimeOriginal.Format("2006:01:02 15:04:05")))
+	}
+	if tags.Lens != "" {
+		addExif = append(addExif, t.ascii(tagLensModel, tags.Lens))
+	}
+
+	ifd0.entries = merge(ifd0.entries, add)
+	if len(addExif) != 0 {
+		exif, ok := ifd0.subs[tagExifIFD]
+		if !ok {
+			exif = &ifd{subs: make(map[uint16]*ifd)}
+		}
+		exif.entries = merge(exif.entries, addExif)
+		ifd0.setSub(tagExifIFD, exif)
+	}
+
+	if g := tags.GPS; g != nil {
+		latRef, lonRef := "N", "E"
+		if g.Lat < 0 {
+			latRef = "S"
+		}
+		if g.Lon < 0 {
+			lonRef = "W"
+		}
+		ifd0.setSub(tagGPSIFD, &ifd{
+			entries: []entry{
+				{tag: tagGPSVersion, typ: typeByte, count: 4, data: []byte{2, 3, 0, 0}},
+				t.ascii(tagGPSLatRef, latRef),
+				t.degrees(tagGPSLat, g.Lat),
+				t.ascii(tagGPSLonRef, lonRef),
+				t.degrees(tagGPSLon, g.Lon),
+			},
+			subs: make(map[uint16]*ifd),
+		})
+	}
+}
+
+// rebuild returns the tiff b with tags embedded, serialized from scratch.
+// Only the thumbnail of IFD1 is kept.
+func rebuild(b []byte, tags Tags) ([]byte, error) {
+	t, off, err := parseTIFF(b)
+	if err != nil {
+		return nil, err
+	}
+	ifd0, err := t.readTree(off, 0)
+	if err != nil {
+		return nil, err
+	}
+	t.apply(ifd0, tags, false)
+
+	out := t.header()
+	var next uint32
+	if ifd0.next != 0 {
+		if next, err = t.thumbnail(out, ifd0.next); err != nil {
+			return nil, err
+		}
+	}
+	if off, err = out.writeTree(ifd0, next); err != nil {
+		return nil, err
+	}
+	out.order.PutUint32(out.b[4:], off)
+	return out.b, nil
+}
+
+// thumbnail copies IFD1 and its jpeg thumbnail from t to out and returns its
+// offset in out, or zero when it isn't a jpeg thumbnail.
+func (t *tiff) thumbnail(out *tiff, off uint32) (uint32, error) {
+	ifd1, err := t.readIFD(off)
+	if err != nil {
+		return 0, err
+	}
+	var start, length uint32
+	for _, e := range ifd1.entries {
+		switch {
+		case e.tag == tagStripOffsets:
+			return 0, nil
+		case e.tag == tagThumbnail && len(e.data) == 4:
+			start = t.order.Uint32(e.data)
+		case e.tag == tagThumbnailLength && len(e.data) == 4:
+			length = t.order.Uint32(e.data)
+		}
+	}
+	if length == 0 || uint64(start)+uint64(length) > uint64(len(t.b)) {
+		return 0, nil
+	}
+
+	out.align()
+	thumb, err := out.offset()
+	if err != nil {
+		return 0, err
+	}
+	out.b = append(out.b, t.b[start:start+length]...)
+	return out.writeIFD(merge(ifd1.entries, []entry{t.long(tagThumbnail, thumb)}), 0)
+}
+
+// update appends the merged IFDs to the tiff b and points the header to the
+// new IFD0, b is returned as is when the tags are already embedded.
+func update(b []byte, tags Tags) ([]byte, error) {
+	t, off, err := parseTIFF(b)
+	if err != nil {
+		return nil, err
+	}
+	ifd0, err := t.readTree(off, 0)
+	if err != nil {
+		return nil, err
+	}
+	merged := ifd0.clone()
+	t.apply(merged, tags, true)
+	if merged.equal(ifd0) {
+		return b, nil
+	}
+
+	if off, err = t.writeTree(merged, merged.next); err != nil {
+		return nil, err
+	}
+	t.order.PutUint32(t.b[4:], off)
+	return t.b, nil
+}
+
+// emptyTIFF is a little endian tiff header with an empty IFD0.
+func emptyTIFF() []byte {
+	return []byte{'I', 'I', 42, 0, 8, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+}
+
+// TIFF returns the tiff file b with tags embedded.
+func TIFF(b []byte, tags Tags) ([]byte, error) {
+	return update(bytes.Clone(b), tags)
+}
@@ -0,0 +1,226 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"testing"
+	"time"
+)
+
+var testTags = Tags{
+	Make:             "Olympus",
+	Model:            "OM-1",
+	Description:      "Portra 400",
+	Lens:             "Zuiko 50mm",
+	ISO:              400,
+	DateTimeOriginal: time.Date(2023, 12, 12, 10, 30, 0, 0, time.UTC),
+	GPS:              &GPS{Lat: 51.05, Lon: -3.72},
+	XMP:              []byte("<x:xmpmeta/>"),
+}
+
+// testTIFF returns a tiff header in the given byte order with a Make and a
+// maker note in IFD0, and optionally an IFD1 with a jpeg thumbnail.
+func testTIFF(order byteOrder, thumb []byte) []byte {
+	t := &tiff{order: order}
+	if order == binary.ByteOrder(binary.BigEndian) {
+		t.b = []byte("MM")
+	} else {
+		t.b = []byte("II")
+	}
+	t.b = order.AppendUint16(t.b, 42)
+	t.b = order.AppendUint32(t.b, 0)
+
+	var next uint32
+	if thumb != nil {
+		off := uint32(len(t.b))
+		t.b = append(t.b, thumb...)
+		next, _ = t.writeIFD([]entry{
+			t.long(tagThumbnail, off),
+			t.long(tagThumbnailLength, uint32(len(thumb))),
+		}, 0)
+	}
+	off, _ := t.writeIFD([]entry{
+		t.ascii(tagMake, "Foo"),
+		{tag: 0x927C, typ: typeUndefined, count: 6, data: []byte("maker!")},
+	}, next)
+	order.PutUint32(t.b[4:], off)
+	return t.b
+}
+
+func testJPEG(t *testing.T, exif []byte) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	if exif == nil {
+		return b
+	}
+	data := append(bytes.Clone(exifHeader), exif...)
+	out := []byte{0xFF, markerSOI, 0xFF, markerAPP1}
+	out = binary.BigEndian.AppendUint16(out, uint16(len(data)+2))
+	out = append(out, data...)
+	return append(out, b[2:]...)
+}
+
+// jpegExif returns the tiff of the Exif segment of the jpeg b.
+func jpegExif(t *testing.T, b []byte) []byte {
+	i := bytes.Index(b, exifHeader)
+	if i < 4 {
+		t.Fatal("no exif segment")
+	}
+	n := int(binary.BigEndian.Uint16(b[i-2:]))
+	return b[i+len(exifHeader) : i-2+n]
+}
+
+func read(t *testing.T, b []byte) (*tiff, *ifd) {
+	tf, off, err := parseTIFF(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ifd0, err := tf.readTree(off, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tf, ifd0
+}
+
+func value(d *ifd, tag uint16) []byte {
+	if d == nil {
+		return nil
+	}
+	for _, e := range d.entries {
+		if e.tag == tag {
+			return e.data
+		}
+	}
+	return nil
+}
+
+func checkTags(t *testing.T, name string, tf *tiff, ifd0 *ifd) {
+	exif := ifd0.subs[tagExifIFD]
+	gps := ifd0.subs[tagGPSIFD]
+	tests := []struct {
+		name string
+		got  []byte
+		want []byte
+	}{
+		{"make", value(ifd0, tagMake), []byte("Olympus\x00")},
+		{"model", value(ifd0, tagModel), []byte("OM-1\x00")},
+		{"description", value(ifd0, tagDescription), []byte("Portra 400\x00")},
+		{"iso", value(exif, tagISO), tf.order.AppendUint16(nil, 400)},
+		{"date", value(exif, tagDateTimeOriginal), []byte("2023:12:12 10:30:00\x00")},
+		{"lens", value(exif, tagLensModel), []byte("Zuiko 50mm\x00")},
+		{"lat ref", value(gps, tagGPSLatRef), []byte("N\x00")},
+		{"lon ref", value(gps, tagGPSLonRef), []byte("W\x00")},
+	}
+	for _, test := range tests {
+		if !bytes.Equal(test.got, test.want) {
+			t.Errorf("%s: %s = %q, want %q", name, test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestJPEG(t *testing.T) {
+	thumb := testJPEG(t, nil)
+	tests := []struct {
+		name  string
+		exif  []byte
+		thumb bool
+	}{
+		{"no exif", nil, false},
+		{"little endian", testTIFF(binary.LittleEndian, nil), false},
+		{"big endian", testTIFF(binary.BigEndian, nil), false},
+		{"thumbnail", testTIFF(binary.BigEndian, thumb), true},
+	}
+
+	for _, test := range tests {
+		b, err := JPEG(testJPEG(t, test.exif), testTags)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if _, err := jpeg.Decode(bytes.NewReader(b)); err != nil {
+			t.Errorf("%s: invalid jpeg: %s", test.name, err)
+		}
+		if !bytes.Contains(b, append(bytes.Clone(xmpHeader), testTags.XMP...)) {
+			t.Errorf("%s: no xmp segment", test.name)
+		}
+
+		tf, ifd0 := read(t, jpegExif(t, b))
+		checkTags(t, test.name, tf, ifd0)
+		if test.exif != nil && string(value(ifd0, 0x927C)) != "maker!" {
+			t.Errorf("%s: maker note lost", test.name)
+		}
+		if test.thumb {
+			ifd1, err := tf.readIFD(ifd0.next)
+			if err != nil {
+				t.Fatalf("%s: %s", test.name, err)
+			}
+			off := tf.order.Uint32(value(ifd1, tagThumbnail))
+			if !bytes.Equal(tf.b[off:off+uint32(len(thumb))], thumb) {
+				t.Errorf("%s: thumbnail lost", test.name)
+			}
+		}
+
+		again, err := JPEG(b, testTags)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if !bytes.Equal(again, b) {
+			t.Errorf("%s: not idempotent, %d bytes became %d", test.name, len(b), len(again))
+		}
+	}
+}
+
+func TestTIFF(t *testing.T) {
+	tests := []struct {
+		name string
+		tiff []byte
+	}{
+		{"empty", emptyTIFF()},
+		{"little endian", testTIFF(binary.LittleEndian, nil)},
+		{"big endian", testTIFF(binary.BigEndian, nil)},
+	}
+
+	for _, test := range tests {
+		b, err := TIFF(test.tiff, testTags)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if !bytes.Equal(b[8:len(test.tiff)], test.tiff[8:]) {
+			t.Errorf("%s: existing data changed", test.name)
+		}
+
+		tf, ifd0 := read(t, b)
+		checkTags(t, test.name, tf, ifd0)
+		if !bytes.Equal(value(ifd0, tagXMP), testTags.XMP) {
+			t.Errorf("%s: xmp = %q", test.name, value(ifd0, tagXMP))
+		}
+
+		again, err := TIFF(b, testTags)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if !bytes.Equal(again, b) {
+			t.Errorf("%s: not idempotent, %d bytes became %d", test.name, len(b), len(again))
+		}
+
+		tags := testTags
+		tags.ISO = 800
+		changed, err := TIFF(b, tags)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		tf, ifd0 = read(t, changed)
+		if iso := value(ifd0.subs[tagExifIFD], tagISO); !bytes.Equal(iso, tf.order.AppendUint16(nil, 800)) {
+			t.Errorf("%s: iso not updated: %v", test.name, iso)
+		}
+	}
+}
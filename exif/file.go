@@ -0,0 +1,75 @@
+package exif
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BackupSuffix is appended to the name of the backup of the original file.
+const BackupSuffix = ".orig"
+
+// Supported reports whether path is a jpeg or tiff file by its extension.
+func Supported(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg", ".tif", ".tiff":
+		return true
+	}
+	return false
+}
+
+// WriteFile embeds tags in the jpeg or tiff file at path. The original file
+// is kept as path+BackupSuffix, unless such a backup already exists.
+func WriteFile(path string, tags Tags) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var out []byte
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg":
+		out, err = JPEG(b, tags)
+	case ".tif", ".tiff":
+		out, err = TIFF(b, tags)
+	default:
+		return fmt.Errorf("%s: unsupported file type", path)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	backup := path + BackupSuffix
+	if _, err := os.Stat(backup); errors.Is(err, fs.ErrNotExist) {
+		if err := os.WriteFile(backup, b, stat.Mode()); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".exif-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(out); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(stat.Mode()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,104 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	markerSOI  = 0xD8
+	markerSOS  = 0xDA
+	markerEOI  = 0xD9
+	markerAPP0 = 0xE0
+	markerAPP1 = 0xE1
+)
+
+var (
+	exifHeader = []byte("Exif\x00\x00")
+	xmpHeader  = []byte("http://ns.adobe.com/xap/1.0/\x00")
+)
+
+type segment struct {
+	marker byte
+	data   []byte
+}
+
+func (s segment) append(b []byte) ([]byte, error) {
+	if len(s.data)+2 > 0xFFFF {
+		return b, fmt.Errorf("jpeg segment too large (%d bytes)", len(s.data))
+	}
+	b = append(b, 0xFF, s.marker)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s.data)+2))
+	return append(b, s.data...), nil
+}
+
+// JPEG returns the jpeg file b with tags embedded in its Exif segment and, if
+// tags.XMP is set, with its XMP segment replaced.
+func JPEG(b []byte, tags Tags) ([]byte, error) {
+	if len(b) < 4 || b[0] != 0xFF || b[1] != markerSOI {
+		return nil, errors.New("not a jpeg file")
+	}
+
+	var segs []segment
+	var exif []byte
+	rest := b[2:]
+	for {
+		if len(rest) < 4 || rest[0] != 0xFF {
+			return nil, errors.New("invalid jpeg segment")
+		}
+		marker := rest[1]
+		if marker == 0xFF {
+			rest = rest[1:]
+			continue
+		}
+		if marker == markerSOS || marker == markerEOI {
+			break
+		}
+		n := int(binary.BigEndian.Uint16(rest[2:]))
+		if n < 2 || n+2 > len(rest) {
+			return nil, errors.New("truncated jpeg segment")
+		}
+		data := rest[4 : n+2]
+		rest = rest[n+2:]
+
+		if marker == markerAPP1 && bytes.HasPrefix(data, exifHeader) {
+			exif = bytes.Clone(data[len(exifHeader):])
+			continue
+		}
+		if marker == markerAPP1 && bytes.HasPrefix(data, xmpHeader) && len(tags.XMP) != 0 {
+			continue
+		}
+		segs = append(segs, segment{marker, data})
+	}
+
+	if exif == nil {
+		exif = emptyTIFF()
+	}
+	exif, err := rebuild(exif, tags)
+	if err != nil {
+		return nil, fmt.Errorf("exif: %w", err)
+	}
+
+	add := []segment{{markerAPP1, append(bytes.Clone(exifHeader), exif...)}}
+	if len(tags.XMP) != 0 {
+		add = append(add, segment{markerAPP1, append(bytes.Clone(xmpHeader), tags.XMP...)})
+	}
+
+	// Keep JFIF first, followed by the new segments.
+	i := 0
+	for i < len(segs) && segs[i].marker == markerAPP0 {
+		i++
+	}
+	segs = append(segs[:i], append(add, segs[i:]...)...)
+
+	out := make([]byte, 0, len(b)+len(exif))
+	out = append(out, 0xFF, markerSOI)
+	for _, s := range segs {
+		if out, err = s.append(out); err != nil {
+			return nil, err
+		}
+	}
+	return append(out, rest...), nil
+}
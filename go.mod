@@ -3,8 +3,11 @@ module github.com/frizinak/film-rolls
 go 1.21.4
 
 require (
-	github.com/containerd/console v1.0.3 // indirect
-	github.com/mattn/go-runewidth v0.0.15 // indirect
+	github.com/containerd/console v1.0.3
+	github.com/mattn/go-runewidth v0.0.15
+)
+
+require (
 	github.com/rivo/uniseg v0.2.0 // indirect
 	golang.org/x/sys v0.0.0-20210124154548-22da62e12c0c // indirect
 )
@@ -0,0 +1,548 @@
+package table
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Renderer writes a Table in a specific output format.
+type Renderer interface {
+	Render(w io.Writer, t *Table) error
+}
+
+func pad(str string, width, w int, a Align) string {
+	n := w - width
+	if n <= 0 {
+		return str
+	}
+	if a == AlignRight {
+		return strings.Repeat(" ", n) + str
+	}
+	return str + strings.Repeat(" ", n)
+}
+
+// Terminal renders space padded columns, honoring each column's prefix and
+// suffix (e.g.: ansi color sequences) and the table's fixed width.
+//
+// When the table is wider than its fixed width, columns are shrunk down to
+// their minimum width. Non-fixed columns without an explicit minimum can
+// shrink to 10 cells, fixed columns without one never shrink.
+// Content that doesn't fit is wrapped onto continuation lines for
+// wrapping columns and truncated with an ellipsis otherwise.
+type Terminal struct {
+	// Sep separates columns.
+	Sep string
+	// JoinSep separates a joined column from the previous one,
+	// defaults to Sep.
+	JoinSep string
+	// Border starts and ends each line with Sep.
+	Border bool
+}
+
+const (
+	ellipsis        = "\u2026"
+	defaultMinWidth = 10
+)
+
+func (r Terminal) Render(wr io.Writer, t *Table) error {
+	n := t.columns()
+	fixed := make([]bool, n)
+	joined := make([]bool, n)
+	wrap := make([]bool, n)
+	minw := make([]int, n)
+	t.each(func(i int, col Col) {
+		fixed[i] = fixed[i] || col.Fixed()
+		joined[i] = joined[i] || colJoined(col)
+		wrap[i] = wrap[i] || colWrap(col)
+		if m := colMinWidth(col); m > minw[i] {
+			minw[i] = m
+		}
+	})
+	w := t.widths()
+
+	joinSep := r.JoinSep
+	if joinSep == "" {
+		joinSep = r.Sep
+	}
+	seps := make([]string, n)
+	for i := 1; i < n; i++ {
+		seps[i] = r.Sep
+		if joined[i] {
+			seps[i] = joinSep
+		}
+	}
+	var lborder, rborder string
+	if r.Border {
+		lborder = strings.TrimLeft(r.Sep, " ")
+		rborder = strings.TrimRight(r.Sep, " ")
+	}
+
+	if t.width != 0 {
+		sum := runewidth.StringWidth(lborder) + runewidth.StringWidth(rborder)
+		for i := range w {
+			sum += w[i] + runewidth.StringWidth(seps[i])
+		}
+
+		for i := range minw {
+			if minw[i] == 0 && !fixed[i] {
+				minw[i] = defaultMinWidth
+			}
+			if minw[i] == 0 || minw[i] > w[i] {
+				minw[i] = w[i]
+			}
+		}
+
+		for sum > t.width {
+			widest := -1
+			for i := range w {
+				if w[i] > minw[i] && (widest == -1 || w[i] > w[widest]) {
+					widest = i
+				}
+			}
+			if widest == -1 {
+				break
+			}
+			w[widest]--
+			sum--
+		}
+
+		func() {
+			if sum >= t.width {
+				return
+			}
+			fix := 0
+			for i := range fixed {
+				if fixed[i] {
+					fix++
+				}
+			}
+
+			widen := (len(w) - fix)
+			if widen <= 0 {
+				return
+			}
+			per := (t.width - sum) / widen
+			rem := (t.width - sum) - (per * widen)
+			for i := range w {
+				if !fixed[i] {
+					w[i] += per + rem
+					rem = 0
+				}
+			}
+		}()
+	}
+
+	lines := make([][]string, n)
+	line := func(row []Col, head bool) error {
+		height := 1
+		for i := 0; i < n; i++ {
+			lines[i] = lines[i][:0]
+			if i >= len(row) {
+				continue
+			}
+			str := row[i].String()
+			switch {
+			case row[i].Width() <= w[i]:
+				lines[i] = append(lines[i], str)
+			case wrap[i]:
+				lines[i] = wordWrap(lines[i], str, w[i])
+			default:
+				lines[i] = append(lines[i], runewidth.Truncate(str, w[i], ellipsis))
+			}
+			if len(lines[i]) > height {
+				height = len(lines[i])
+			}
+		}
+
+		var b strings.Builder
+		for l := 0; l < height; l++ {
+			b.WriteString(lborder)
+			for i := 0; i < n; i++ {
+				b.WriteString(seps[i])
+				if l >= len(lines[i]) {
+					b.WriteString(strings.Repeat(" ", w[i]))
+					continue
+				}
+				col := row[i]
+				a := col.Align()
+				if head {
+					a = AlignLeft
+				}
+				str := lines[i][l]
+				b.WriteString(col.Prefix())
+				b.WriteString(pad(str, runewidth.StringWidth(str), w[i], a))
+				b.WriteString(col.Suffix())
+			}
+			b.WriteString(rborder)
+			b.WriteString("\n")
+		}
+		_, err := io.WriteString(wr, b.String())
+		return err
+	}
+
+	group := func(title Col, first bool) error {
+		total := 0
+		for i := range w {
+			total += w[i] + runewidth.StringWidth(seps[i])
+		}
+		str := title.String()
+		if title.Width() > total {
+			str = runewidth.Truncate(str, total, ellipsis)
+		}
+
+		var b strings.Builder
+		if !first {
+			b.WriteString("\n")
+		}
+		b.WriteString(lborder)
+		b.WriteString(title.Prefix())
+		b.WriteString(pad(str, runewidth.StringWidth(str), total, AlignLeft))
+		b.WriteString(title.Suffix())
+		b.WriteString(rborder)
+		b.WriteString("\n")
+		_, err := io.WriteString(wr, b.String())
+		return err
+	}
+
+	if len(t.head) != 0 {
+		if err := line(t.head, true); err != nil {
+			return err
+		}
+	}
+	for r, row := range t.rows {
+		var err error
+		switch t.kinds[r] {
+		case RowGroup:
+			err = group(row[0], r == 0)
+		default:
+			err = line(row, false)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// wordWrap appends the lines of str wrapped at width w to dst.
+// Words wider than w are split.
+func wordWrap(dst []string, str string, w int) []string {
+	if w <= 0 {
+		return append(dst, str)
+	}
+
+	var cur strings.Builder
+	curw := 0
+	flush := func() {
+		dst = append(dst, cur.String())
+		cur.Reset()
+		curw = 0
+	}
+
+	for _, word := range strings.Fields(str) {
+		ww := runewidth.StringWidth(word)
+		if curw != 0 && curw+1+ww > w {
+			flush()
+		}
+		if curw != 0 {
+			cur.WriteByte(' ')
+			curw++
+		}
+		for ww > w-curw {
+			var part strings.Builder
+			pw := 0
+			rest := ""
+			for j, r := range word {
+				rw := runewidth.RuneWidth(r)
+				if pw != 0 && pw+rw > w-curw {
+					rest = word[j:]
+					break
+				}
+				part.WriteRune(r)
+				pw += rw
+			}
+			cur.WriteString(part.String())
+			curw += pw
+			flush()
+			word, ww = rest, runewidth.StringWidth(rest)
+		}
+		cur.WriteString(word)
+		curw += ww
+	}
+	if curw != 0 || len(dst) == 0 {
+		flush()
+	}
+
+	return dst
+}
+
+func cell(row []Col, i int) string {
+	if i >= len(row) {
+		return ""
+	}
+	return row[i].String()
+}
+
+// Markdown renders a github flavored markdown table.
+type Markdown struct{}
+
+func (r Markdown) Render(wr io.Writer, t *Table) error {
+	esc := strings.NewReplacer("|", "\\|")
+	n := t.columns()
+	aligns := t.aligns()
+	w := make([]int, n)
+	for i := range w {
+		w[i] = 4
+	}
+	t.each(func(i int, col Col) {
+		if wi := runewidth.StringWidth(esc.Replace(col.String())); wi > w[i] {
+			w[i] = wi
+		}
+	})
+
+	line := func(cols []string) error {
+		_, err := fmt.Fprintf(wr, "| %s |\n", strings.Join(cols, " | "))
+		return err
+	}
+
+	cols := make([]string, n)
+	for i := range cols {
+		str := esc.Replace(cell(t.head, i))
+		cols[i] = pad(str, runewidth.StringWidth(str), w[i], AlignLeft)
+	}
+	if err := line(cols); err != nil {
+		return err
+	}
+
+	for i := range cols {
+		cols[i] = ":" + strings.Repeat("-", w[i]-1)
+		if aligns[i] == AlignRight {
+			cols[i] = strings.Repeat("-", w[i]-1) + ":"
+		}
+	}
+	if err := line(cols); err != nil {
+		return err
+	}
+
+	for r, row := range t.rows {
+		for i := range cols {
+			str := esc.Replace(cell(row, i))
+			if t.kinds[r] != RowNormal && str != "" {
+				str = "**" + str + "**"
+			}
+			cols[i] = pad(str, runewidth.StringWidth(str), w[i], aligns[i])
+		}
+		if err := line(cols); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HTML renders an html <table> element.
+type HTML struct{}
+
+func (r HTML) Render(wr io.Writer, t *Table) error {
+	n := t.columns()
+	aligns := t.aligns()
+	var b strings.Builder
+	tr := func(row []Col, tag string, kind RowKind) {
+		switch kind {
+		case RowGroup:
+			fmt.Fprintf(&b, "<tr class=\"group\"><th colspan=\"%d\">", n)
+			b.WriteString(html.EscapeString(cell(row, 0)))
+			b.WriteString("</th></tr>\n")
+			return
+		case RowSummary:
+			b.WriteString(`<tr class="summary">`)
+		default:
+			b.WriteString("<tr>")
+		}
+		for i := 0; i < n; i++ {
+			b.WriteString("<" + tag)
+			if aligns[i] == AlignRight {
+				b.WriteString(` style="text-align:right"`)
+			}
+			b.WriteString(">")
+			b.WriteString(html.EscapeString(cell(row, i)))
+			b.WriteString("</" + tag + ">")
+		}
+		b.WriteString("</tr>\n")
+	}
+
+	b.WriteString("<table>\n")
+	if len(t.head) != 0 {
+		b.WriteString("<thead>\n")
+		tr(t.head, "th", RowNormal)
+		b.WriteString("</thead>\n")
+	}
+	b.WriteString("<tbody>\n")
+	for r, row := range t.rows {
+		tr(row, "td", t.kinds[r])
+	}
+	b.WriteString("</tbody>\n")
+	b.WriteString("</table>\n")
+
+	_, err := io.WriteString(wr, b.String())
+	return err
+}
+
+// CSV renders comma separated values.
+type CSV struct {
+	// Comma overrides the field delimiter.
+	Comma rune
+}
+
+func (r CSV) Render(wr io.Writer, t *Table) error {
+	n := t.columns()
+	c := csv.NewWriter(wr)
+	if r.Comma != 0 {
+		c.Comma = r.Comma
+	}
+	rec := make([]string, n)
+	write := func(row []Col) error {
+		for i := range rec {
+			rec[i] = cell(row, i)
+		}
+		return c.Write(rec)
+	}
+
+	if len(t.head) != 0 {
+		if err := write(t.head); err != nil {
+			return err
+		}
+	}
+	for _, row := range t.rows {
+		if err := write(row); err != nil {
+			return err
+		}
+	}
+
+	c.Flush()
+	return c.Error()
+}
+
+// AsciiDoc renders an asciidoc table block.
+type AsciiDoc struct{}
+
+func (r AsciiDoc) Render(wr io.Writer, t *Table) error {
+	esc := strings.NewReplacer("|", "\\|")
+	n := t.columns()
+	aligns := t.aligns()
+	var b strings.Builder
+
+	spec := make([]string, n)
+	for i := range spec {
+		spec[i] = "<1"
+		if aligns[i] == AlignRight {
+			spec[i] = ">1"
+		}
+	}
+	fmt.Fprintf(&b, "[cols=\"%s\"", strings.Join(spec, ","))
+	if len(t.head) != 0 {
+		b.WriteString(`,options="header"`)
+	}
+	b.WriteString("]\n|===\n")
+
+	tr := func(row []Col, kind RowKind) {
+		if kind == RowGroup {
+			fmt.Fprintf(&b, "%d+h|%s\n", n, esc.Replace(cell(row, 0)))
+			return
+		}
+		for i := 0; i < n; i++ {
+			if i != 0 {
+				b.WriteString(" ")
+			}
+			if kind == RowSummary {
+				b.WriteString("s")
+			}
+			b.WriteString("|")
+			b.WriteString(esc.Replace(cell(row, i)))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(t.head) != 0 {
+		tr(t.head, RowNormal)
+		b.WriteString("\n")
+	}
+	for r, row := range t.rows {
+		tr(row, t.kinds[r])
+	}
+	b.WriteString("|===\n")
+
+	_, err := io.WriteString(wr, b.String())
+	return err
+}
+
+// Org renders an emacs org-mode table.
+type Org struct{}
+
+func (r Org) Render(wr io.Writer, t *Table) error {
+	esc := strings.NewReplacer("|", "\\vert{}")
+	n := t.columns()
+	aligns := t.aligns()
+	w := make([]int, n)
+	t.each(func(i int, col Col) {
+		if wi := runewidth.StringWidth(esc.Replace(col.String())); wi > w[i] {
+			w[i] = wi
+		}
+	})
+
+	var b strings.Builder
+	tr := func(row []Col, head bool) {
+		b.WriteString("|")
+		for i := 0; i < n; i++ {
+			a := aligns[i]
+			if head {
+				a = AlignLeft
+			}
+			str := esc.Replace(cell(row, i))
+			b.WriteString(" ")
+			b.WriteString(pad(str, runewidth.StringWidth(str), w[i], a))
+			b.WriteString(" |")
+		}
+		b.WriteString("\n")
+	}
+
+	hline := func() {
+		b.WriteString("|")
+		for i := 0; i < n; i++ {
+			if i != 0 {
+				b.WriteString("+")
+			}
+			b.WriteString(strings.Repeat("-", w[i]+2))
+		}
+		b.WriteString("|\n")
+	}
+
+	if len(t.head) != 0 {
+		tr(t.head, true)
+		hline()
+	}
+	for r, row := range t.rows {
+		switch t.kinds[r] {
+		case RowGroup:
+			if r != 0 {
+				hline()
+			}
+			fmt.Fprintf(&b, "| %s\n", esc.Replace(cell(row, 0)))
+			hline()
+		case RowSummary:
+			hline()
+			tr(row, false)
+		default:
+			tr(row, false)
+		}
+	}
+
+	_, err := io.WriteString(wr, b.String())
+	return err
+}
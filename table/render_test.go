@@ -0,0 +1,134 @@
+package table
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTerminalRender(t *testing.T) {
+	const long = "abcdefghijklmnopqrstuvwxyz"
+	tests := []struct {
+		name  string
+		width int
+		rows  [][]Col
+		want  string
+	}{
+		{
+			"no shrink",
+			10,
+			[][]Col{TermStrs("id", "name"), TermStrs("1", "alpha")},
+			"id | name \n1  | alpha\n",
+		},
+		{
+			"zero width",
+			0,
+			[][]Col{TermStrs("id", "name"), TermStrs("1", "alpha")},
+			"id | name \n1  | alpha\n",
+		},
+		{
+			"widen",
+			14,
+			[][]Col{TermStrs("id", "name"), TermStrs("1", "alpha")},
+			"id   | name   \n1    | alpha  \n",
+		},
+		{
+			"shrink",
+			20,
+			[][]Col{{ColFixed(TermStr("1")), TermStr(long)}},
+			"1 | abcdefghijklmno…\n",
+		},
+		{
+			"default min width",
+			5,
+			[][]Col{{ColFixed(TermStr("1")), TermStr(long)}},
+			"1 | abcdefghi…\n",
+		},
+		{
+			"min width floor",
+			5,
+			[][]Col{{ColFixed(TermStr("1")), ColMinWidth(TermStr(long), 12)}},
+			"1 | abcdefghijk…\n",
+		},
+		{
+			"fixed never shrinks",
+			5,
+			[][]Col{{ColFixed(TermStr(long)), ColFixed(TermStr("1"))}},
+			long + " | 1\n",
+		},
+		{
+			"wrap",
+			14,
+			[][]Col{
+				{ColFixed(TermStr("1")), ColWrap(TermStr("the quick brown fox"))},
+				{ColFixed(TermStr("2")), ColWrap(TermStr("jumps"))},
+			},
+			"1 | the quick \n  | brown fox \n2 | jumps     \n",
+		},
+		{
+			"multi-byte truncate",
+			14,
+			[][]Col{{ColFixed(TermStr("1")), TermStr("日本語のテキスト")}},
+			"1 | 日本語の… \n",
+		},
+		{
+			"multi-byte wrap",
+			14,
+			[][]Col{{ColFixed(TermStr("1")), ColWrap(TermStr("日本語のテキスト"))}},
+			"1 | 日本語のテ\n  | キスト    \n",
+		},
+	}
+
+	for _, test := range tests {
+		tbl := New()
+		tbl.SetFixedWidth(test.width)
+		for _, row := range test.rows {
+			tbl.AddRow(row...)
+		}
+
+		var b strings.Builder
+		if err := (Terminal{Sep: " | "}).Render(&b, tbl); err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if b.String() != test.want {
+			t.Errorf("%s: got\n%q\nwant\n%q", test.name, b.String(), test.want)
+		}
+	}
+}
+
+func TestWordWrap(t *testing.T) {
+	tests := []struct {
+		str  string
+		w    int
+		want []string
+	}{
+		{"", 5, []string{""}},
+		{"a b c", 0, []string{"a b c"}},
+		{"a b c", 3, []string{"a b", "c"}},
+		{"a  b", 10, []string{"a b"}},
+		{"abcdefg", 3, []string{"abc", "def", "g"}},
+		{"ab cdefg", 4, []string{"ab", "cdef", "g"}},
+		{"日本語", 3, []string{"日", "本", "語"}},
+		{"日本", 1, []string{"日", "本"}},
+	}
+
+	for _, test := range tests {
+		if got := wordWrap(nil, test.str, test.w); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("wordWrap(%q, %d) = %q, want %q", test.str, test.w, got, test.want)
+		}
+	}
+}
+
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) { return 0, errors.New("write failed") }
+
+func TestWriteToError(t *testing.T) {
+	tbl := New()
+	tbl.AddRow(TermStrs("a", "b")...)
+	if err := tbl.WriteTo(errWriter{}, " "); err == nil {
+		t.Error("expected the write error")
+	}
+}
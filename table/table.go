@@ -1,10 +1,7 @@
 package table
 
 import (
-	"fmt"
 	"io"
-	"strconv"
-	"strings"
 	"unicode/utf8"
 
 	"github.com/mattn/go-runewidth"
@@ -26,9 +23,85 @@ type Col interface {
 	Fixed() bool
 }
 
+// JoinedCol is optionally implemented by a Col that is visually joined with
+// the previous column.
+type JoinedCol interface {
+	Joined() bool
+}
+
+// MinWidthCol is optionally implemented by a Col that shouldn't be truncated
+// below a given width.
+type MinWidthCol interface {
+	MinWidth() int
+}
+
+// WrapCol is optionally implemented by a Col that wraps instead of being
+// truncated.
+type WrapCol interface {
+	Wrap() bool
+}
+
+// unwrapper is implemented by the Col decorators in this package so the
+// optional interfaces of the decorated Col are found.
+type unwrapper interface {
+	unwrap() Col
+}
+
+// option returns the first value of the optional interface T found in col or
+// the Cols it decorates.
+func option[T any](col Col) (T, bool) {
+	for {
+		if v, ok := col.(T); ok {
+			return v, true
+		}
+		u, ok := col.(unwrapper)
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		col = u.unwrap()
+	}
+}
+
+func colJoined(col Col) bool {
+	j, ok := option[JoinedCol](col)
+	return ok && j.Joined()
+}
+
+func colWrap(col Col) bool {
+	w, ok := option[WrapCol](col)
+	return ok && w.Wrap()
+}
+
+func colMinWidth(col Col) int {
+	if m, ok := option[MinWidthCol](col); ok {
+		return m.MinWidth()
+	}
+	return 0
+}
+
 type fixed struct{ Col }
 
 func (f fixed) Fixed() bool { return true }
+func (f fixed) unwrap() Col { return f.Col }
+
+type joined struct{ Col }
+
+func (j joined) Joined() bool { return true }
+func (j joined) unwrap() Col  { return j.Col }
+
+type minWidth struct {
+	Col
+	w int
+}
+
+func (m minWidth) MinWidth() int { return m.w }
+func (m minWidth) unwrap() Col   { return m.Col }
+
+type wrapped struct{ Col }
+
+func (w wrapped) Wrap() bool  { return true }
+func (w wrapped) unwrap() Col { return w.Col }
 
 type prefixed struct {
 	Col
@@ -36,6 +109,7 @@ type prefixed struct {
 }
 
 func (p prefixed) Prefix() string { return p.prefix }
+func (p prefixed) unwrap() Col    { return p.Col }
 
 type suffixed struct {
 	Col
@@ -43,6 +117,7 @@ type suffixed struct {
 }
 
 func (s suffixed) Suffix() string { return s.suffix }
+func (s suffixed) unwrap() Col    { return s.Col }
 
 type aligned struct {
 	Col
@@ -50,10 +125,14 @@ type aligned struct {
 }
 
 func (a aligned) Align() Align { return a.align }
+func (a aligned) unwrap() Col  { return a.Col }
 
 func ColAlignLeft(col Col) Col               { return aligned{col, AlignLeft} }
 func ColAlignRight(col Col) Col              { return aligned{col, AlignRight} }
 func ColFixed(col Col) Col                   { return fixed{col} }
+func ColJoined(col Col) Col                  { return joined{col} }
+func ColMinWidth(col Col, w int) Col         { return minWidth{col, w} }
+func ColWrap(col Col) Col                    { return wrapped{col} }
 func ColPrefixed(col Col, prefix string) Col { return prefixed{col, prefix} }
 func ColSuffixed(col Col, suffix string) Col { return suffixed{col, suffix} }
 func ColPreSuf(col Col, prefix, suffix string) Col {
@@ -102,21 +181,46 @@ func Strs(strs ...string) []Col {
 	return c
 }
 
+type RowKind uint8
+
+const (
+	RowNormal RowKind = iota
+	// RowGroup is a group header with a single column spanning the table.
+	RowGroup
+	// RowSummary summarizes the rows above it, e.g.: subtotals.
+	RowSummary
+)
+
 type Table struct {
 	width int
 	head  []Col
 	rows  [][]Col
+	kinds []RowKind
 }
 
 func New() *Table {
-	return &Table{0, make([]Col, 0), make([][]Col, 0)}
+	return &Table{0, make([]Col, 0), make([][]Col, 0), make([]RowKind, 0)}
 }
 
 func (t *Table) SetFixedWidth(w int) {
 	t.width = w
 }
 
-func (t *Table) NewRow() { t.rows = append(t.rows, make([]Col, 0)) }
+func (t *Table) NewRow() { t.newRow(RowNormal) }
+
+// NewSummaryRow starts a row summarizing the rows above it.
+func (t *Table) NewSummaryRow() { t.newRow(RowSummary) }
+
+// AddGroup adds a group header that spans all columns.
+func (t *Table) AddGroup(title Col) {
+	t.newRow(RowGroup)
+	t.AddCol(title)
+}
+
+func (t *Table) newRow(kind RowKind) {
+	t.rows = append(t.rows, make([]Col, 0))
+	t.kinds = append(t.kinds, kind)
+}
 
 func (t *Table) AddRow(cols ...Col) {
 	t.NewRow()
@@ -129,88 +233,65 @@ func (t *Table) AddCol(col Col) {
 	if len(t.rows) == 0 {
 		panic("can't add col without a row")
 	}
+	if t.kinds[len(t.rows)-1] == RowGroup && len(t.rows[len(t.rows)-1]) != 0 {
+		panic("can't add col to a group header")
+	}
 	t.rows[len(t.rows)-1] = append(t.rows[len(t.rows)-1], col)
 }
 
 func (t *Table) AddHeadCol(value Col) { t.head = append(t.head, value) }
 
-func (t *Table) WriteTo(wr io.Writer, sep string) {
-	widest := len(t.head)
+// WriteTo renders the table using the Terminal renderer.
+func (t *Table) WriteTo(wr io.Writer, sep string) error {
+	return Terminal{Sep: sep}.Render(wr, t)
+}
+
+func (t *Table) columns() int {
+	n := len(t.head)
 	for i := range t.rows {
-		if len(t.rows[i]) > widest {
-			widest = len(t.rows[i])
+		if t.kinds[i] != RowGroup && len(t.rows[i]) > n {
+			n = len(t.rows[i])
 		}
 	}
+	return n
+}
 
-	fixed := make([]bool, widest)
-	w := make([]int, widest)
-	for i := range t.head {
-		fixed[i] = fixed[i] || t.head[i].Fixed()
-		w[i] = t.head[i].Width()
+// each calls cb for all header cells and cells in non group rows.
+func (t *Table) each(cb func(i int, col Col)) {
+	for i, col := range t.head {
+		cb(i, col)
 	}
-
-	for _, row := range t.rows {
+	for r, row := range t.rows {
+		if t.kinds[r] == RowGroup {
+			continue
+		}
 		for i, col := range row {
-			fixed[i] = fixed[i] || col.Fixed()
-			if wi := col.Width(); wi > w[i] {
-				w[i] = wi
-			}
+			cb(i, col)
 		}
 	}
+}
 
-	if t.width != 0 {
-		sum := 0
-		for i := range w {
-			sum += w[i]
+func (t *Table) widths() []int {
+	w := make([]int, t.columns())
+	t.each(func(i int, col Col) {
+		if wi := col.Width(); wi > w[i] {
+			w[i] = wi
 		}
+	})
+	return w
+}
 
-		func() {
-			if sum >= t.width {
-				return
-			}
-			fix := 0
-			for i := range fixed {
-				if fixed[i] {
-					fix++
-				}
-			}
-
-			widen := (len(w) - fix)
-			if widen <= 0 {
-				return
-			}
-			per := (t.width - sum) / widen
-			rem := (t.width - sum) - (per * widen)
-			for i := range w {
-				if !fixed[i] {
-					w[i] += per + rem
-					rem = 0
-				}
-			}
-		}()
-	}
-
-	f := make([]string, 0, len(t.head))
-	strs := make([]any, 0, len(t.head))
-	for i, r := range t.head {
-		f = append(f, "%s%-"+strconv.Itoa(w[i])+"s%s")
-		strs = append(strs, r.Prefix(), r.String(), r.Suffix())
-	}
-	if len(strs) != 0 {
-		fmt.Fprintf(wr, strings.Join(f, sep)+"\n", strs...)
-	}
-
-	for _, row := range t.rows {
-		f = f[:0]
-		strs = strs[:0]
+func (t *Table) aligns() []Align {
+	a := make([]Align, t.columns())
+	for r, row := range t.rows {
+		if t.kinds[r] == RowGroup {
+			continue
+		}
 		for i, col := range row {
-			a := "-"
 			if col.Align() == AlignRight {
-				a = ""
+				a[i] = AlignRight
 			}
-			f = append(f, "%s%"+a+strconv.Itoa(w[i])+"s%s")
-			strs = append(strs, col.Prefix(), col.String(), col.Suffix())
 		}
-		fmt.Fprintf(wr, strings.Join(f, sep)+"\n", strs...)
 	}
+	return a
 }